@@ -0,0 +1,180 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/ava-labs/ava-sim/events"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/proxy"
+	"github.com/ava-labs/ava-sim/utils"
+)
+
+// EventChaos is the type of the events recorded for every step
+const EventChaos = "chaos"
+
+// Actions a step can take
+const (
+	ActionStop       = "stop"
+	ActionStart      = "start"
+	ActionRestart    = "restart"
+	ActionPartition  = "partition"
+	ActionHeal       = "heal"
+	ActionLink       = "link"
+	ActionResetLinks = "reset-links"
+)
+
+// Scenario is a list of steps run against a network
+type Scenario struct {
+	Name  string `json:"name,omitempty"`
+	Steps []Step `json:"steps"`
+}
+
+// Step is an action taken at a given time after the scenario starts
+type Step struct {
+	At     proxy.Duration `json:"at"`
+	Action string         `json:"action"`
+
+	// Node is the node (starting at 1) stop, start and restart act on
+	Node int `json:"node,omitempty"`
+	// AvalancheGoPath replaces the binary of the node on restart
+	AvalancheGoPath string `json:"avalanchegoPath,omitempty"`
+	// Groups are the groups of nodes of a partition
+	Groups [][]int `json:"groups,omitempty"`
+	// Duration heals a partition after it has been in place this long
+	Duration proxy.Duration `json:"duration,omitempty"`
+	// From, To, OneWay and Link describe the links a link step configures,
+	// as for the /faults/links endpoint
+	From   []int            `json:"from,omitempty"`
+	To     []int            `json:"to,omitempty"`
+	OneWay bool             `json:"oneWay,omitempty"`
+	Link   proxy.LinkConfig `json:"link,omitempty"`
+}
+
+func (s Step) String() string {
+	switch s.Action {
+	case ActionStop, ActionStart:
+		return fmt.Sprintf("%s node%d", s.Action, s.Node)
+	case ActionRestart:
+		if len(s.AvalancheGoPath) > 0 {
+			return fmt.Sprintf("restart node%d with %s", s.Node, s.AvalancheGoPath)
+		}
+		return fmt.Sprintf("restart node%d", s.Node)
+	case ActionPartition:
+		if s.Duration > 0 {
+			return fmt.Sprintf("partition %v for %s", s.Groups, s.Duration)
+		}
+		return fmt.Sprintf("partition %v", s.Groups)
+	case ActionLink:
+		return fmt.Sprintf("set links from %v to %v to %+v", s.From, s.To, s.Link)
+	default:
+		return s.Action
+	}
+}
+
+// Load reads a scenario from a JSON file
+func Load(path string) (Scenario, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	var scenario Scenario
+	if err := json.Unmarshal(b, &scenario); err != nil {
+		return Scenario{}, fmt.Errorf("could not parse scenario %s: %w", path, err)
+	}
+	return scenario, scenario.Verify()
+}
+
+// Verify checks that every step of the scenario can be run
+func (s Scenario) Verify() error {
+	for i, step := range s.Steps {
+		if step.At < 0 {
+			return fmt.Errorf("step %d: negative time %s", i+1, step.At)
+		}
+		switch step.Action {
+		case ActionStop, ActionStart, ActionRestart:
+			if step.Node < 1 {
+				return fmt.Errorf("step %d: %s needs a node", i+1, step.Action)
+			}
+			if len(step.AvalancheGoPath) > 0 {
+				if err := utils.CheckExecutable(step.AvalancheGoPath); err != nil {
+					return fmt.Errorf("step %d: %w", i+1, err)
+				}
+			}
+		case ActionPartition:
+			if len(step.Groups) == 0 {
+				return fmt.Errorf("step %d: partition needs groups", i+1)
+			}
+		case ActionHeal, ActionLink, ActionResetLinks:
+		default:
+			return fmt.Errorf("step %d: unknown action %q", i+1, step.Action)
+		}
+	}
+	return nil
+}
+
+// Run runs the steps of [scenario] against [network] at their time, recording
+// every one of them in [log]. It stops at the first step that fails.
+func Run(ctx context.Context, network *manager.Network, scenario Scenario, log *events.Log) error {
+	if err := scenario.Verify(); err != nil {
+		return err
+	}
+	steps := append([]Step{}, scenario.Steps...)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].At < steps[j].At
+	})
+
+	name := scenario.Name
+	if len(name) == 0 {
+		name = "scenario"
+	}
+	log.Record(EventChaos, "%s started (%d steps)", name, len(steps))
+	start := time.Now()
+	for _, step := range steps {
+		select {
+		case <-time.After(time.Until(start.Add(time.Duration(step.At)))):
+		case <-ctx.Done():
+			log.Record(EventChaos, "%s interrupted: %s", name, ctx.Err())
+			return ctx.Err()
+		}
+
+		log.Record(EventChaos, "+%s %s", step.At, step)
+		if err := runStep(network, step); err != nil {
+			log.Record(EventChaos, "%s failed: %s: %s", name, step, err)
+			return fmt.Errorf("%s: %w", step, err)
+		}
+	}
+	log.Record(EventChaos, "%s completed after %s", name, time.Since(start).Truncate(time.Millisecond))
+	return nil
+}
+
+func runStep(network *manager.Network, step Step) error {
+	switch step.Action {
+	case ActionStop:
+		return network.StopNode(step.Node)
+	case ActionStart:
+		return network.StartNode(step.Node)
+	case ActionRestart:
+		return network.RestartNode(step.Node, step.AvalancheGoPath)
+	}
+
+	links, err := network.Links()
+	if err != nil {
+		return err
+	}
+	switch step.Action {
+	case ActionPartition:
+		return links.Partition(step.Groups, time.Duration(step.Duration))
+	case ActionHeal:
+		links.Heal()
+	case ActionLink:
+		return links.SetLinks(step.From, step.To, step.OneWay, step.Link)
+	case ActionResetLinks:
+		links.ResetLinks()
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package chaos
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/ava-sim/proxy"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr bool
+	}{
+		{
+			name: "stop",
+			step: Step{Action: ActionStop, Node: 1},
+		},
+		{
+			name:    "negative time",
+			step:    Step{At: proxy.Duration(-time.Second), Action: ActionStop, Node: 1},
+			wantErr: true,
+		},
+		{
+			name:    "no node",
+			step:    Step{Action: ActionRestart},
+			wantErr: true,
+		},
+		{
+			name: "restart with binary",
+			step: Step{Action: ActionRestart, Node: 2, AvalancheGoPath: "/bin/true"},
+		},
+		{
+			name:    "restart with missing binary",
+			step:    Step{Action: ActionRestart, Node: 2, AvalancheGoPath: filepath.Join(t.TempDir(), "avalanchego")},
+			wantErr: true,
+		},
+		{
+			name:    "partition without groups",
+			step:    Step{Action: ActionPartition},
+			wantErr: true,
+		},
+		{
+			name: "heal",
+			step: Step{Action: ActionHeal},
+		},
+		{
+			name:    "unknown action",
+			step:    Step{Action: "crash"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Scenario{Steps: []Step{test.step}}.Verify()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -14,6 +14,10 @@ const (
 	BaseHTTPPort = 9650
 	NumNodes     = 5
 
+	// ava-sim serves its own API next to the nodes
+	APIPort = 9640
+	APIURL  = "http://127.0.0.1:9640"
+
 	FilePerms = 0o777
 )
 
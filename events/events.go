@@ -0,0 +1,194 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+
+	"github.com/fatih/color"
+)
+
+// Types of the events marking the lifecycle of the network
+const (
+	NodeStarted      = "node-started"
+	NodeBootstrapped = "node-bootstrapped"
+	PeersConnected   = "peers-connected"
+	TxIssued         = "tx-issued"
+	TxCommitted      = "tx-committed"
+	ChainValidating  = "chain-validating"
+	EndpointsReady   = "endpoints-ready"
+	NodeExited       = "node-exited"
+	// NodeRestartFailed marks a node left stopped as it couldn't be started
+	// again after a planned restart
+	NodeRestartFailed = "node-restart-failed"
+)
+
+const (
+	// MaxEvents is how many of the last events are kept in memory. The file
+	// of the log keeps all of them.
+	MaxEvents = 1000
+
+	sinkUnixPrefix = "unix:"
+	sinkTCPPrefix  = "tcp:"
+
+	sinkDialTimeout = 10 * time.Second
+	// sinkWriteTimeout bounds how long a socket may block the network
+	sinkWriteTimeout = time.Second
+)
+
+// Event is something that happened to the network, such as a node starting or
+// a chaos action
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+
+	// Node is the node (starting at 1) the event is about, if any
+	Node   int    `json:"node,omitempty"`
+	NodeID string `json:"nodeID,omitempty"`
+	// Chain is the alias or ID of the chain the event is about, if any
+	Chain string `json:"chain,omitempty"`
+	TxID  string `json:"txID,omitempty"`
+	// URI is the endpoint of an endpoints-ready event
+	URI      string `json:"uri,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+}
+
+// sink is a destination events are written to as JSON lines
+type sink struct {
+	name   string
+	writer io.WriteCloser
+}
+
+// Log records events as JSON lines in a file, and in any sink added to it, and
+// keeps the last [MaxEvents] of them in memory so they can be served over the
+// API. A nil log drops every event.
+type Log struct {
+	lock  sync.Mutex
+	sinks []sink
+	// events is a ring buffer whose oldest event is at [oldest] once it is
+	// full
+	events []Event
+	oldest int
+}
+
+// New creates a log writing to [path]
+func New(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, constants.FilePerms)
+	if err != nil {
+		return nil, fmt.Errorf("could not create event log: %w", err)
+	}
+	return &Log{sinks: []sink{{name: path, writer: file}}}, nil
+}
+
+// AddSink writes the events recorded from now on to [target] as well, which is
+// either a file, unix:<path> for a unix socket or tcp:<host:port>
+func (l *Log) AddSink(target string) error {
+	var (
+		writer io.WriteCloser
+		err    error
+	)
+	switch {
+	case strings.HasPrefix(target, sinkUnixPrefix):
+		writer, err = net.DialTimeout("unix", strings.TrimPrefix(target, sinkUnixPrefix), sinkDialTimeout)
+	case strings.HasPrefix(target, sinkTCPPrefix):
+		writer, err = net.DialTimeout("tcp", strings.TrimPrefix(target, sinkTCPPrefix), sinkDialTimeout)
+	default:
+		writer, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, constants.FilePerms)
+	}
+	if err != nil {
+		return fmt.Errorf("could not open event sink %s: %w", target, err)
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.sinks = append(l.sinks, sink{name: target, writer: writer})
+	return nil
+}
+
+// Record adds an event of type [eventType] to the log and prints it
+func (l *Log) Record(eventType string, format string, args ...interface{}) {
+	event := Event{
+		Time:    time.Now().UTC(),
+		Type:    eventType,
+		Message: fmt.Sprintf(format, args...),
+	}
+	color.Magenta("[%s] %s: %s", event.Time.Format("15:04:05.000"), event.Type, event.Message)
+	l.Emit(event)
+}
+
+// Emit adds [event] to the log without printing it, for events whose progress
+// is already reported on the console
+func (l *Log) Emit(event Event) {
+	if l == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.events) < MaxEvents {
+		l.events = append(l.events, event)
+	} else {
+		l.events[l.oldest] = event
+		l.oldest = (l.oldest + 1) % MaxEvents
+	}
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	eventBytes = append(eventBytes, '\n')
+
+	// Sinks that fail are dropped so that a reader going away doesn't stall
+	// the network
+	sinks := l.sinks[:0]
+	for _, s := range l.sinks {
+		if conn, ok := s.writer.(net.Conn); ok {
+			conn.SetWriteDeadline(time.Now().Add(sinkWriteTimeout))
+		}
+		if _, err := s.writer.Write(eventBytes); err != nil {
+			color.Red("could not write events to %s: %s", s.name, err)
+			s.writer.Close()
+			continue
+		}
+		sinks = append(sinks, s)
+	}
+	l.sinks = sinks
+}
+
+// Events returns the last [MaxEvents] events recorded, oldest first
+func (l *Log) Events() []Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	events := make([]Event, 0, len(l.events))
+	events = append(events, l.events[l.oldest:]...)
+	return append(events, l.events[:l.oldest]...)
+}
+
+// Close closes the file and the sinks of the log. Events recorded afterwards
+// are only kept in memory.
+func (l *Log) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var err error
+	for _, s := range l.sinks {
+		if closeErr := s.writer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	l.sinks = nil
+	return err
+}
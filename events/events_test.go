@@ -0,0 +1,74 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestLogKeepsLastEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		numEvents int
+	}{
+		{name: "none", numEvents: 0},
+		{name: "below the limit", numEvents: 10},
+		{name: "at the limit", numEvents: MaxEvents},
+		{name: "above the limit", numEvents: MaxEvents + 10},
+		{name: "wrapped around twice", numEvents: 2*MaxEvents + 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.jsonl")
+			l, err := New(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < test.numEvents; i++ {
+				l.Emit(Event{Type: NodeStarted, Message: strconv.Itoa(i)})
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			events := l.Events()
+			wantKept := test.numEvents
+			if wantKept > MaxEvents {
+				wantKept = MaxEvents
+			}
+			if len(events) != wantKept {
+				t.Fatalf("got %d events, want %d", len(events), wantKept)
+			}
+			for i, event := range events {
+				if want := strconv.Itoa(test.numEvents - wantKept + i); event.Message != want {
+					t.Fatalf("event %d is %q, want %q", i, event.Message, want)
+				}
+			}
+
+			// The file keeps every event
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			written := 0
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var event Event
+				if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+					t.Fatal(err)
+				}
+				if want := strconv.Itoa(written); event.Message != want {
+					t.Fatalf("line %d is %q, want %q", written, event.Message, want)
+				}
+				written++
+			}
+			if written != test.numEvents {
+				t.Fatalf("got %d events in the file, want %d", written, test.numEvents)
+			}
+		})
+	}
+}
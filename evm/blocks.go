@@ -0,0 +1,157 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/rpc"
+)
+
+// blockPollFrequency is how often a new block is looked for when the node
+// can't notify new heads
+const blockPollFrequency = 500 * time.Millisecond
+
+var errBlockNotFound = errors.New("block not found")
+
+// Block holds the fields of a block ava-sim reports on. Blocks are read as
+// returned by the node rather than through libevm types, which miss the fields
+// Avalanche EVMs add to headers.
+type Block struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	// Time is the block timestamp, to the millisecond once the chain records
+	// it
+	Time     time.Time `json:"time"`
+	TxCount  int       `json:"txCount"`
+	GasUsed  uint64    `json:"gasUsed"`
+	GasLimit uint64    `json:"gasLimit"`
+	// BaseFee and BlockGasCost are nil before the upgrades introducing them
+	BaseFee      *big.Int `json:"baseFee,omitempty"`
+	BlockGasCost *big.Int `json:"blockGasCost,omitempty"`
+	// TxHashes are the hashes of the txs of the block, in order
+	TxHashes []common.Hash `json:"-"`
+}
+
+// rpcBlock is a block as returned by eth_getBlockByNumber
+type rpcBlock struct {
+	Number                *hexutil.Big      `json:"number"`
+	Hash                  common.Hash       `json:"hash"`
+	Timestamp             hexutil.Uint64    `json:"timestamp"`
+	TimestampMilliseconds *hexutil.Uint64   `json:"timestampMilliseconds"`
+	GasUsed               hexutil.Uint64    `json:"gasUsed"`
+	GasLimit              hexutil.Uint64    `json:"gasLimit"`
+	BaseFee               *hexutil.Big      `json:"baseFeePerGas"`
+	BlockGasCost          *hexutil.Big      `json:"blockGasCost"`
+	ExtDataGasUsed        *hexutil.Big      `json:"extDataGasUsed"`
+	Transactions          []json.RawMessage `json:"transactions"`
+}
+
+func (b *rpcBlock) block() Block {
+	block := Block{
+		Number:   b.Number.ToInt().Uint64(),
+		Hash:     b.Hash,
+		Time:     time.Unix(int64(b.Timestamp), 0),
+		TxCount:  len(b.Transactions),
+		GasUsed:  uint64(b.GasUsed),
+		GasLimit: uint64(b.GasLimit),
+	}
+	if b.TimestampMilliseconds != nil {
+		block.Time = time.UnixMilli(int64(*b.TimestampMilliseconds))
+	}
+	if b.BaseFee != nil {
+		block.BaseFee = b.BaseFee.ToInt()
+	}
+	if b.BlockGasCost != nil {
+		block.BlockGasCost = b.BlockGasCost.ToInt()
+	}
+	for _, txJSON := range b.Transactions {
+		// Txs are either hashes or objects holding their hash
+		var tx struct {
+			Hash common.Hash `json:"hash"`
+		}
+		if err := json.Unmarshal(txJSON, &tx.Hash); err != nil {
+			json.Unmarshal(txJSON, &tx)
+		}
+		block.TxHashes = append(block.TxHashes, tx.Hash)
+	}
+	return block
+}
+
+// BlockByNumber returns block [number], or the last accepted block if it is
+// nil
+func BlockByNumber(ctx context.Context, client *rpc.Client, number *big.Int) (Block, error) {
+	b, err := getBlock(ctx, client, number, false)
+	if err != nil {
+		return Block{}, err
+	}
+	return b.block(), nil
+}
+
+// getBlock returns block [number] with its txs in full if [fullTxs] is set, or
+// only their hashes
+func getBlock(ctx context.Context, client *rpc.Client, number *big.Int, fullTxs bool) (*rpcBlock, error) {
+	var b *rpcBlock
+	if err := client.CallContext(ctx, &b, "eth_getBlockByNumber", toBlockNumArg(number), fullTxs); err != nil {
+		return nil, err
+	}
+	if b == nil || b.Number == nil {
+		return nil, fmt.Errorf("%w: %s", errBlockNotFound, toBlockNumArg(number))
+	}
+	return b, nil
+}
+
+// StreamBlocks calls [fn] with every block from [from] on, in order, as they
+// are accepted, until [ctx] is cancelled or [fn] fails. New blocks are
+// notified over [client] if it is a websocket connection, and polled for
+// otherwise or if the subscription fails.
+func StreamBlocks(ctx context.Context, client *rpc.Client, from uint64, fn func(Block) error) error {
+	heads := make(chan json.RawMessage, 1)
+	var subErr <-chan error
+	if sub, err := client.EthSubscribe(ctx, heads, "newHeads"); err == nil {
+		defer sub.Unsubscribe()
+		subErr = sub.Err()
+	}
+	ticker := time.NewTicker(blockPollFrequency)
+	defer ticker.Stop()
+
+	next := from
+	for {
+		// Blocks accepted since the last notification are read one by one
+		for {
+			block, err := BlockByNumber(ctx, client, new(big.Int).SetUint64(next))
+			if errors.Is(err, errBlockNotFound) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := fn(block); err != nil {
+				return err
+			}
+			next++
+		}
+
+		select {
+		case <-heads:
+		case <-ticker.C:
+		case <-subErr:
+			// The ticker keeps polling for new blocks
+			subErr = nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
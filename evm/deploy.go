@@ -0,0 +1,259 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	ethereum "github.com/ava-labs/libevm"
+	"github.com/ava-labs/libevm/accounts/abi"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/ethclient"
+)
+
+var (
+	errInvalidArtifact = errors.New("invalid artifact")
+	errInvalidArg      = errors.New("invalid constructor argument")
+)
+
+// Artifact is the bytecode and constructor of a compiled contract
+type Artifact struct {
+	// Name is empty if the artifact doesn't name the contract
+	Name        string
+	Bytecode    []byte
+	Constructor abi.Arguments
+}
+
+// artifactJSON holds the fields of the artifacts written by Hardhat, Foundry
+// and solc --standard-json that the bytecode and ABI of a contract are read
+// from
+type artifactJSON struct {
+	ContractName string          `json:"contractName"`
+	ABI          json.RawMessage `json:"abi"`
+	// Bytecode is a string for Hardhat, and an object for Foundry
+	Bytecode json.RawMessage `json:"bytecode"`
+	EVM      struct {
+		Bytecode struct {
+			Object string `json:"object"`
+		} `json:"bytecode"`
+	} `json:"evm"`
+}
+
+// ParseArtifact reads the JSON artifact of a compiled contract
+func ParseArtifact(artifactBytes []byte) (Artifact, error) {
+	var a artifactJSON
+	if err := json.Unmarshal(artifactBytes, &a); err != nil {
+		return Artifact{}, fmt.Errorf("%w: %s", errInvalidArtifact, err)
+	}
+
+	code := a.EVM.Bytecode.Object
+	if len(a.Bytecode) > 0 {
+		var bytecode struct {
+			Object string `json:"object"`
+		}
+		if err := json.Unmarshal(a.Bytecode, &code); err != nil {
+			if err := json.Unmarshal(a.Bytecode, &bytecode); err != nil {
+				return Artifact{}, fmt.Errorf("%w: bytecode is neither a string nor an object", errInvalidArtifact)
+			}
+			code = bytecode.Object
+		}
+	}
+	bytecode, err := ParseBytecode(code)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	artifact := Artifact{
+		Name:     a.ContractName,
+		Bytecode: bytecode,
+	}
+	if len(a.ABI) > 0 {
+		contractABI, err := abi.JSON(bytes.NewReader(a.ABI))
+		if err != nil {
+			return Artifact{}, fmt.Errorf("%w: could not parse ABI: %s", errInvalidArtifact, err)
+		}
+		artifact.Constructor = contractABI.Constructor.Inputs
+	}
+	return artifact, nil
+}
+
+// ParseBytecode decodes hex bytecode, with or without its 0x prefix
+func ParseBytecode(code string) ([]byte, error) {
+	code = strings.TrimPrefix(strings.TrimSpace(code), "0x")
+	if len(code) == 0 {
+		return nil, fmt.Errorf("%w: empty bytecode", errInvalidArtifact)
+	}
+	if strings.Contains(code, "__") {
+		return nil, fmt.Errorf("%w: bytecode has unlinked libraries", errInvalidArtifact)
+	}
+	bytecode, err := hexutil.Decode("0x" + code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not decode bytecode: %s", errInvalidArtifact, err)
+	}
+	return bytecode, nil
+}
+
+// ParseConstructor returns the arguments of a constructor signature such as
+// "constructor(string,uint256)". Tuples aren't supported.
+func ParseConstructor(signature string) (abi.Arguments, error) {
+	open, closing := strings.Index(signature, "("), strings.LastIndex(signature, ")")
+	if open < 0 || closing < open {
+		return nil, fmt.Errorf("invalid constructor signature %q", signature)
+	}
+	var args abi.Arguments
+	for _, typeName := range strings.Split(signature[open+1:closing], ",") {
+		typeName = strings.TrimSpace(typeName)
+		if len(typeName) == 0 {
+			continue
+		}
+		t, err := abi.NewType(typeName, "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constructor signature %q: %w", signature, err)
+		}
+		args = append(args, abi.Argument{Type: t})
+	}
+	return args, nil
+}
+
+// PackConstructorArgs ABI-encodes [values], given as strings, as the
+// arguments of [constructor]. Arrays are given as JSON arrays.
+func PackConstructorArgs(constructor abi.Arguments, values []string) ([]byte, error) {
+	if len(values) != len(constructor) {
+		return nil, fmt.Errorf("%w: the constructor takes %d arguments, got %d", errInvalidArg, len(constructor), len(values))
+	}
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		arg, err := parseArg(constructor[i].Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d (%s): %s", errInvalidArg, i, constructor[i].Type, err)
+		}
+		args[i] = arg.Interface()
+	}
+	return constructor.Pack(args...)
+}
+
+// parseArg converts [value] to the Go type [t] is packed from
+func parseArg(t abi.Type, value string) (reflect.Value, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(value, 0)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%q is not an integer", value)
+		}
+		goType := t.GetType()
+		if goType == reflect.TypeOf(n) {
+			return reflect.ValueOf(n), nil
+		}
+		v := reflect.New(goType).Elem()
+		if t.T == abi.IntTy {
+			if !n.IsInt64() || v.OverflowInt(n.Int64()) {
+				return reflect.Value{}, fmt.Errorf("%s overflows %s", value, t)
+			}
+			v.SetInt(n.Int64())
+		} else {
+			if !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+				return reflect.Value{}, fmt.Errorf("%s overflows %s", value, t)
+			}
+			v.SetUint(n.Uint64())
+		}
+		return v, nil
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(b), nil
+	case abi.StringTy:
+		return reflect.ValueOf(value), nil
+	case abi.AddressTy:
+		if !common.IsHexAddress(value) {
+			return reflect.Value{}, fmt.Errorf("%q is not an address", value)
+		}
+		return reflect.ValueOf(common.HexToAddress(value)), nil
+	case abi.BytesTy:
+		b, err := hexutil.Decode(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(b), nil
+	case abi.FixedBytesTy:
+		b, err := hexutil.Decode(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(b) > t.Size {
+			return reflect.Value{}, fmt.Errorf("%d bytes don't fit in %s", len(b), t)
+		}
+		v := reflect.New(t.GetType()).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+	case abi.SliceTy, abi.ArrayTy:
+		var elems []json.RawMessage
+		if err := json.Unmarshal([]byte(value), &elems); err != nil {
+			return reflect.Value{}, fmt.Errorf("%q is not a JSON array", value)
+		}
+		if t.T == abi.ArrayTy && len(elems) != t.Size {
+			return reflect.Value{}, fmt.Errorf("%s holds %d elements, got %d", t, t.Size, len(elems))
+		}
+		v := reflect.New(t.GetType()).Elem()
+		if t.T == abi.SliceTy {
+			v = reflect.MakeSlice(t.GetType(), len(elems), len(elems))
+		}
+		for i, elem := range elems {
+			// Elements are JSON strings or, for numbers and booleans, bare
+			elemValue := string(elem)
+			var s string
+			if err := json.Unmarshal(elem, &s); err == nil {
+				elemValue = s
+			}
+			e, err := parseArg(*t.Elem, elemValue)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(e)
+		}
+		return v, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// Deploy deploys a contract created by [code], which holds its bytecode and
+// packed constructor arguments, from [key] with [value] sent to its
+// constructor, and returns the receipt of its creation
+func Deploy(ctx context.Context, client *ethclient.Client, key *ecdsa.PrivateKey, code []byte, value *big.Int) (*types.Receipt, error) {
+	from := Address(key)
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("could not get nonce: %w", err)
+	}
+	gas, err := client.EstimateGas(ctx, ethereum.CallMsg{
+		From:  from,
+		Value: value,
+		Data:  code,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not estimate gas: %w", err)
+	}
+	tx, err := SendTx(ctx, client, key, nonce, nil, value, gas, code)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := WaitReceipt(ctx, client, tx.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("could not get receipt of tx %s: %w", tx.Hash(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("deployment tx %s reverted", tx.Hash())
+	}
+	return receipt, nil
+}
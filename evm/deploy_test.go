@@ -0,0 +1,229 @@
+package evm
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ava-labs/libevm/accounts/abi"
+	"github.com/ava-labs/libevm/common"
+)
+
+func TestParseArtifact(t *testing.T) {
+	const abiJSON = `[{"type":"constructor","inputs":[{"name":"name","type":"string"},{"name":"supply","type":"uint256"}]}]`
+	tests := []struct {
+		name      string
+		artifact  string
+		wantName  string
+		wantCode  []byte
+		wantTypes []string
+		wantErr   bool
+	}{
+		{
+			name:      "hardhat",
+			artifact:  `{"contractName":"Token","abi":` + abiJSON + `,"bytecode":"0x6080"}`,
+			wantName:  "Token",
+			wantCode:  []byte{0x60, 0x80},
+			wantTypes: []string{"string", "uint256"},
+		},
+		{
+			name:     "foundry",
+			artifact: `{"abi":[],"bytecode":{"object":"0x6080","linkReferences":{}}}`,
+			wantCode: []byte{0x60, 0x80},
+		},
+		{
+			name:     "solc",
+			artifact: `{"abi":[],"evm":{"bytecode":{"object":"6080"}}}`,
+			wantCode: []byte{0x60, 0x80},
+		},
+		{
+			name:     "unlinked libraries",
+			artifact: `{"bytecode":"0x6080__$1234$__"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "no bytecode",
+			artifact: `{"abi":[]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "bytecode of another type",
+			artifact: `{"bytecode":1}`,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid JSON",
+			artifact: `{"bytecode":`,
+			wantErr:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			artifact, err := ParseArtifact([]byte(test.artifact))
+			if test.wantErr {
+				if !errors.Is(err, errInvalidArtifact) {
+					t.Fatalf("got error %v, want %v", err, errInvalidArtifact)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if artifact.Name != test.wantName {
+				t.Fatalf("got name %q, want %q", artifact.Name, test.wantName)
+			}
+			if !reflect.DeepEqual(artifact.Bytecode, test.wantCode) {
+				t.Fatalf("got bytecode %x, want %x", artifact.Bytecode, test.wantCode)
+			}
+			if got := argTypes(artifact.Constructor); !reflect.DeepEqual(got, test.wantTypes) {
+				t.Fatalf("got constructor %v, want %v", got, test.wantTypes)
+			}
+		})
+	}
+}
+
+func TestParseConstructor(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "no arguments",
+			signature: "constructor()",
+		},
+		{
+			name:      "arguments",
+			signature: "constructor(string, uint256,address[],bytes32)",
+			want:      []string{"string", "uint256", "address[]", "bytes32"},
+		},
+		{
+			name:      "without parentheses",
+			signature: "string,uint256",
+			wantErr:   true,
+		},
+		{
+			name:      "unknown type",
+			signature: "constructor(uint256,token)",
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := ParseConstructor(test.signature)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			if got := argTypes(args); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPackConstructorArgs(t *testing.T) {
+	address := common.HexToAddress("0x8db97c7cece249c2b98bdc0226cc4c2a57bf52fc")
+	tests := []struct {
+		name      string
+		signature string
+		values    []string
+		want      []interface{}
+		wantErr   bool
+	}{
+		{
+			name:      "integers",
+			signature: "constructor(uint8,int64,uint256)",
+			values:    []string{"255", "-5", "0x10"},
+			want:      []interface{}{uint8(255), int64(-5), big.NewInt(16)},
+		},
+		{
+			name:      "overflow",
+			signature: "constructor(uint8)",
+			values:    []string{"256"},
+			wantErr:   true,
+		},
+		{
+			name:      "negative unsigned",
+			signature: "constructor(uint32)",
+			values:    []string{"-1"},
+			wantErr:   true,
+		},
+		{
+			name:      "bool, string and address",
+			signature: "constructor(bool,string,address)",
+			values:    []string{"true", "token", address.Hex()},
+			want:      []interface{}{true, "token", address},
+		},
+		{
+			name:      "invalid address",
+			signature: "constructor(address)",
+			values:    []string{"0x1234"},
+			wantErr:   true,
+		},
+		{
+			name:      "bytes",
+			signature: "constructor(bytes,bytes4)",
+			values:    []string{"0x0102", "0xa9059c"},
+			want:      []interface{}{[]byte{1, 2}, [4]byte{0xa9, 0x05, 0x9c}},
+		},
+		{
+			name:      "fixed bytes too long",
+			signature: "constructor(bytes2)",
+			values:    []string{"0x010203"},
+			wantErr:   true,
+		},
+		{
+			name:      "arrays",
+			signature: "constructor(uint16[],bool[2],address[])",
+			values:    []string{`[1,"2"]`, `[true,false]`, `["` + address.Hex() + `"]`},
+			want:      []interface{}{[]uint16{1, 2}, [2]bool{true, false}, []common.Address{address}},
+		},
+		{
+			name:      "fixed array of another length",
+			signature: "constructor(uint8[2])",
+			values:    []string{`[1]`},
+			wantErr:   true,
+		},
+		{
+			name:      "too few arguments",
+			signature: "constructor(string,uint256)",
+			values:    []string{"token"},
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			constructor, err := ParseConstructor(test.signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := PackConstructorArgs(constructor, test.values)
+			if test.wantErr {
+				if !errors.Is(err, errInvalidArg) {
+					t.Fatalf("got error %v, want %v", err, errInvalidArg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := constructor.Pack(test.want...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %x, want %x", got, want)
+			}
+		})
+	}
+}
+
+func argTypes(args abi.Arguments) []string {
+	var types []string
+	for _, arg := range args {
+		types = append(types, arg.Type.String())
+	}
+	return types
+}
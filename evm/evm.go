@@ -0,0 +1,175 @@
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/genesis"
+	ethereum "github.com/ava-labs/libevm"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/ava-labs/libevm/ethclient"
+)
+
+// TransferGas is the gas used by a transfer to an account without code
+const TransferGas = 21_000
+
+// SubnetEVMID is the ID subnet-evm is registered under
+const SubnetEVMID = "srEXiWaHuhNyGwPUi444Tu47ZEDwxTWrbQiuD7FmgSAQ6X7Dy"
+
+const (
+	receiptPollFreq = 250 * time.Millisecond
+	baseFeeHeadroom = 2
+)
+
+// RPCURL returns the JSON-RPC endpoint of [chain], an alias such as "C" or a
+// blockchain ID, on the node at [nodeURI]
+func RPCURL(nodeURI string, chain string) string {
+	return fmt.Sprintf("%s/ext/bc/%s/rpc", nodeURI, chain)
+}
+
+// IsEVM reports whether [chain] serves the Ethereum JSON-RPC API on [nodeURI]
+func IsEVM(ctx context.Context, nodeURI string, chain string) bool {
+	client, err := ethclient.DialContext(ctx, RPCURL(nodeURI, chain))
+	if err != nil {
+		return false
+	}
+	defer client.Close()
+
+	_, err = client.ChainID(ctx)
+	return err == nil
+}
+
+// WSURL returns the websocket endpoint of [chain] on the node at [nodeURI]
+func WSURL(nodeURI string, chain string) string {
+	return fmt.Sprintf("%s/ext/bc/%s/ws", strings.Replace(nodeURI, "http", "ws", 1), chain)
+}
+
+// FundedKey returns the key funded in the genesis of the C-chain and of the
+// Subnet-EVM genesis shipped in scripts/
+func FundedKey() *ecdsa.PrivateKey {
+	return genesis.EWOQKey.ToECDSA()
+}
+
+// Address returns the address of [key]
+func Address(key *ecdsa.PrivateKey) common.Address {
+	return crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// Transfer sends [value] from [key] to [to] and returns the issued tx without
+// waiting for it to be accepted
+func Transfer(ctx context.Context, client *ethclient.Client, key *ecdsa.PrivateKey, to common.Address, value *big.Int) (*types.Transaction, error) {
+	nonce, err := client.PendingNonceAt(ctx, Address(key))
+	if err != nil {
+		return nil, fmt.Errorf("could not get nonce: %w", err)
+	}
+	return SendTx(ctx, client, key, nonce, &to, value, TransferGas, nil)
+}
+
+// SendTx signs a dynamic fee tx with [key] and issues it. The fee cap leaves
+// room for the base fee to double before the tx is included.
+func SendTx(
+	ctx context.Context,
+	client *ethclient.Client,
+	key *ecdsa.PrivateKey,
+	nonce uint64,
+	to *common.Address,
+	value *big.Int,
+	gas uint64,
+	data []byte,
+) (*types.Transaction, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get chain ID: %w", err)
+	}
+	fees, err := SuggestFees(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := SignTx(key, chainID, nonce, to, value, gas, data, fees)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("could not issue tx %s: %w", tx.Hash(), err)
+	}
+	return tx, nil
+}
+
+// Fees are the fees a dynamic fee tx offers to pay per gas
+type Fees struct {
+	Tip    *big.Int
+	FeeCap *big.Int
+}
+
+// SuggestFees returns the tip suggested by [client] and a fee cap leaving room
+// for the base fee to double
+func SuggestFees(ctx context.Context, client *ethclient.Client) (Fees, error) {
+	tip, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return Fees{}, fmt.Errorf("could not get gas tip: %w", err)
+	}
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return Fees{}, fmt.Errorf("could not get latest header: %w", err)
+	}
+	feeCap := new(big.Int).Set(tip)
+	if head.BaseFee != nil {
+		feeCap.Add(feeCap, new(big.Int).Mul(head.BaseFee, big.NewInt(baseFeeHeadroom)))
+	}
+	return Fees{Tip: tip, FeeCap: feeCap}, nil
+}
+
+// SignTx signs a dynamic fee tx of [chainID] with [key]
+func SignTx(
+	key *ecdsa.PrivateKey,
+	chainID *big.Int,
+	nonce uint64,
+	to *common.Address,
+	value *big.Int,
+	gas uint64,
+	data []byte,
+	fees Fees,
+) (*types.Transaction, error) {
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: fees.Tip,
+		GasFeeCap: fees.FeeCap,
+		Gas:       gas,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not sign tx: %w", err)
+	}
+	return tx, nil
+}
+
+// WaitReceipt polls [client] until the receipt of [txHash] is available
+func WaitReceipt(ctx context.Context, client *ethclient.Client, txHash common.Hash) (*types.Receipt, error) {
+	ticker := time.NewTicker(receiptPollFreq)
+	defer ticker.Stop()
+	for {
+		receipt, err := client.TransactionReceipt(ctx, txHash)
+		switch {
+		case err == nil:
+			return receipt, nil
+		case !errors.Is(err, ethereum.NotFound):
+			return nil, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/rpc"
+)
+
+// BlockFees are the fees paid in a block
+type BlockFees struct {
+	Number  uint64   `json:"number"`
+	TxCount int      `json:"txCount"`
+	BaseFee *big.Int `json:"baseFee,omitempty"`
+	GasUsed uint64   `json:"gasUsed"`
+	// ExtDataGasUsed is the gas used by the atomic txs of a C-chain block
+	ExtDataGasUsed uint64   `json:"extDataGasUsed"`
+	BlockGasCost   *big.Int `json:"blockGasCost,omitempty"`
+	// Tips holds the priority fee per gas every tx paid on top of the base
+	// fee, sorted
+	Tips []*big.Int `json:"tips"`
+	// GasPrices holds the effective gas price of every tx, sorted
+	GasPrices []*big.Int `json:"gasPrices"`
+}
+
+// rpcTx holds the fields of a tx returned by eth_getBlockByNumber its fees are
+// computed from
+type rpcTx struct {
+	GasPrice             *hexutil.Big `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+}
+
+// fees returns the tip and effective gas price paid by the tx in a block with
+// [baseFee]. Txs without a fee cap pay their whole gas price.
+func (tx *rpcTx) fees(baseFee *big.Int) (*big.Int, *big.Int) {
+	if tx.MaxFeePerGas == nil || tx.MaxPriorityFeePerGas == nil || baseFee == nil {
+		price := new(big.Int)
+		if tx.GasPrice != nil {
+			price = tx.GasPrice.ToInt()
+		}
+		tip := new(big.Int).Set(price)
+		if baseFee != nil {
+			tip.Sub(tip, baseFee)
+		}
+		if tip.Sign() < 0 {
+			tip.SetInt64(0)
+		}
+		return tip, price
+	}
+
+	tip := new(big.Int).Sub(tx.MaxFeePerGas.ToInt(), baseFee)
+	if maxTip := tx.MaxPriorityFeePerGas.ToInt(); tip.Cmp(maxTip) > 0 {
+		tip.Set(maxTip)
+	}
+	return tip, new(big.Int).Add(baseFee, tip)
+}
+
+// BlockFeesByNumber returns the fees paid in block [number]
+func BlockFeesByNumber(ctx context.Context, client *rpc.Client, number *big.Int) (BlockFees, error) {
+	b, err := getBlock(ctx, client, number, true)
+	if err != nil {
+		return BlockFees{}, err
+	}
+	block := b.block()
+	fees := BlockFees{
+		Number:       block.Number,
+		TxCount:      block.TxCount,
+		BaseFee:      block.BaseFee,
+		GasUsed:      block.GasUsed,
+		BlockGasCost: block.BlockGasCost,
+	}
+	if b.ExtDataGasUsed != nil {
+		fees.ExtDataGasUsed = b.ExtDataGasUsed.ToInt().Uint64()
+	}
+	for _, txJSON := range b.Transactions {
+		var tx rpcTx
+		if err := json.Unmarshal(txJSON, &tx); err != nil {
+			return BlockFees{}, fmt.Errorf("could not parse tx of block %d: %w", block.Number, err)
+		}
+		tip, price := tx.fees(block.BaseFee)
+		fees.Tips = append(fees.Tips, tip)
+		fees.GasPrices = append(fees.GasPrices, price)
+	}
+	SortBig(fees.Tips)
+	SortBig(fees.GasPrices)
+	return fees, nil
+}
+
+// SortBig sorts [values] in increasing order
+func SortBig(values []*big.Int) {
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) < 0
+	})
+}
+
+// Percentile returns the [p]th percentile of [sorted] by nearest rank, or nil
+// if it is empty
+func Percentile(sorted []*big.Int, p float64) *big.Int {
+	if len(sorted) == 0 {
+		return nil
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// Gwei formats [wei] in gwei, or returns an empty string if it is nil
+func Gwei(wei *big.Int) string {
+	if wei == nil {
+		return ""
+	}
+	gwei := new(big.Rat).SetFrac(wei, big.NewInt(1_000_000_000))
+	s := gwei.FloatString(9)
+	// Trailing zeros of the fractional part are dropped
+	for s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
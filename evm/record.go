@@ -0,0 +1,167 @@
+package evm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/rpc"
+)
+
+var errInvalidRecording = errors.New("invalid recording")
+
+// RecordedTx is a line of a recording. It holds either a signed tx, in [Raw],
+// or the sender and call of a tx.
+type RecordedTx struct {
+	// OffsetMs is the time, in milliseconds, from the start of the recording
+	OffsetMs int64         `json:"offsetMs"`
+	Raw      hexutil.Bytes `json:"raw,omitempty"`
+
+	From *common.Address `json:"from,omitempty"`
+	// To is nil for contract creations
+	To    *common.Address `json:"to,omitempty"`
+	Value *big.Int        `json:"value,omitempty"`
+	Data  hexutil.Bytes   `json:"data,omitempty"`
+	// Gas is estimated when replaying if it is 0
+	Gas uint64 `json:"gas,omitempty"`
+	// Nonce is the nonce of the tx on the recorded chain. It is needed to
+	// find the address of the contracts created by the tx.
+	Nonce *uint64 `json:"nonce,omitempty"`
+}
+
+// Call is what a recorded tx does, whoever replays it
+type Call struct {
+	From  common.Address
+	To    *common.Address
+	Value *big.Int
+	Data  []byte
+	Gas   uint64
+	Nonce *uint64
+}
+
+// Call decodes the tx if it is signed, and returns its call
+func (r RecordedTx) Call() (Call, error) {
+	if len(r.Raw) == 0 {
+		if r.From == nil {
+			return Call{}, fmt.Errorf("%w: a tx needs either raw or from", errInvalidRecording)
+		}
+		value := r.Value
+		if value == nil {
+			value = new(big.Int)
+		}
+		return Call{
+			From:  *r.From,
+			To:    r.To,
+			Value: value,
+			Data:  r.Data,
+			Gas:   r.Gas,
+			Nonce: r.Nonce,
+		}, nil
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(r.Raw); err != nil {
+		return Call{}, fmt.Errorf("%w: could not decode raw tx: %s", errInvalidRecording, err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return Call{}, fmt.Errorf("%w: could not recover sender of tx %s: %s", errInvalidRecording, tx.Hash(), err)
+	}
+	nonce := tx.Nonce()
+	return Call{
+		From:  from,
+		To:    tx.To(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+		Gas:   tx.Gas(),
+		Nonce: &nonce,
+	}, nil
+}
+
+// ReadRecording reads a recording written as one JSON tx per line. Empty lines
+// are skipped.
+func ReadRecording(r io.Reader) ([]RecordedTx, error) {
+	var (
+		txs     []RecordedTx
+		scanner = bufio.NewScanner(r)
+		line    = 0
+	)
+	// Lines hold whole txs, which can be much longer than the default limit
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var tx RecordedTx
+		if err := json.Unmarshal(scanner.Bytes(), &tx); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s", errInvalidRecording, line, err)
+		}
+		if _, err := tx.Call(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		txs = append(txs, tx)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// rpcCallTx holds the fields of a tx returned by eth_getBlockByNumber that
+// make up its call
+type rpcCallTx struct {
+	Hash  common.Hash     `json:"hash"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Input hexutil.Bytes   `json:"input"`
+	Gas   hexutil.Uint64  `json:"gas"`
+	Nonce hexutil.Uint64  `json:"nonce"`
+}
+
+// RecordBlock returns block [number] and its txs, with their offset left to
+// the caller. The txs are recorded signed if [raw] is set, or as calls.
+func RecordBlock(ctx context.Context, client *rpc.Client, number uint64, raw bool) (Block, []RecordedTx, error) {
+	b, err := getBlock(ctx, client, new(big.Int).SetUint64(number), true)
+	if err != nil {
+		return Block{}, nil, err
+	}
+	txs := make([]RecordedTx, 0, len(b.Transactions))
+	for _, txJSON := range b.Transactions {
+		var tx rpcCallTx
+		if err := json.Unmarshal(txJSON, &tx); err != nil {
+			return Block{}, nil, fmt.Errorf("could not decode tx of block %d: %w", number, err)
+		}
+		if raw {
+			var rawTx hexutil.Bytes
+			if err := client.CallContext(ctx, &rawTx, "eth_getRawTransactionByHash", tx.Hash); err != nil {
+				return Block{}, nil, fmt.Errorf("could not get raw tx %s: %w", tx.Hash, err)
+			}
+			txs = append(txs, RecordedTx{Raw: rawTx})
+			continue
+		}
+		from, nonce := tx.From, uint64(tx.Nonce)
+		recorded := RecordedTx{
+			From:  &from,
+			To:    tx.To,
+			Value: new(big.Int),
+			Data:  tx.Input,
+			Gas:   uint64(tx.Gas),
+			Nonce: &nonce,
+		}
+		if tx.Value != nil {
+			recorded.Value = tx.Value.ToInt()
+		}
+		txs = append(txs, recorded)
+	}
+	return b.block(), txs, nil
+}
@@ -0,0 +1,135 @@
+package evm
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+)
+
+func TestRecordedTxCall(t *testing.T) {
+	var (
+		from  = common.HexToAddress("0x8db97c7cece249c2b98bdc0226cc4c2a57bf52fc")
+		to    = common.HexToAddress("0x1a2b")
+		nonce = uint64(4)
+	)
+	key := FundedKey()
+	signed, err := SignTx(key, big.NewInt(43112), 7, &to, big.NewInt(5), 21000, []byte{1, 2}, Fees{Tip: big.NewInt(1), FeeCap: big.NewInt(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedNonce := uint64(7)
+
+	tests := []struct {
+		name    string
+		tx      RecordedTx
+		want    Call
+		wantErr bool
+	}{
+		{
+			name: "call",
+			tx: RecordedTx{
+				From:  &from,
+				To:    &to,
+				Value: big.NewInt(1000),
+				Data:  []byte{0xa9},
+				Gas:   60000,
+				Nonce: &nonce,
+			},
+			want: Call{From: from, To: &to, Value: big.NewInt(1000), Data: []byte{0xa9}, Gas: 60000, Nonce: &nonce},
+		},
+		{
+			name: "creation without value",
+			tx:   RecordedTx{From: &from, Data: []byte{0x60}},
+			want: Call{From: from, Value: new(big.Int), Data: []byte{0x60}},
+		},
+		{
+			name: "signed",
+			tx:   RecordedTx{Raw: raw},
+			want: Call{From: Address(key), To: &to, Value: big.NewInt(5), Data: []byte{1, 2}, Gas: 21000, Nonce: &signedNonce},
+		},
+		{
+			name:    "without from",
+			tx:      RecordedTx{To: &to},
+			wantErr: true,
+		},
+		{
+			name:    "invalid raw",
+			tx:      RecordedTx{Raw: []byte{0x02, 0xff}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.tx.Call()
+			if test.wantErr {
+				if !errors.Is(err, errInvalidRecording) {
+					t.Fatalf("got error %v, want %v", err, errInvalidRecording)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadRecording(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int64
+		wantErr string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "empty lines skipped",
+			input: "\n{\"offsetMs\":0,\"from\":\"0x8db97c7cece249c2b98bdc0226cc4c2a57bf52fc\"}\n  \n{\"offsetMs\":1500,\"from\":\"0x8db97c7cece249c2b98bdc0226cc4c2a57bf52fc\"}\n",
+			want:  []int64{0, 1500},
+		},
+		{
+			name:    "invalid JSON",
+			input:   "{\"offsetMs\":0,\"from\":\"0x8db97c7cece249c2b98bdc0226cc4c2a57bf52fc\"}\n\n{\"offsetMs\":",
+			wantErr: "line 3",
+		},
+		{
+			name:    "invalid tx",
+			input:   "{\"offsetMs\":0}",
+			wantErr: "line 1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			txs, err := ReadRecording(strings.NewReader(test.input))
+			if len(test.wantErr) > 0 {
+				if !errors.Is(err, errInvalidRecording) || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %s", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var offsets []int64
+			for _, tx := range txs {
+				offsets = append(offsets, tx.OffsetMs)
+			}
+			if !reflect.DeepEqual(offsets, test.want) {
+				t.Fatalf("got offsets %v, want %v", offsets, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/rpc"
+)
+
+// prestateDiffTracer configures debug_traceBlockByNumber to return the state
+// every tx changed
+var prestateDiffTracer = map[string]interface{}{
+	"tracer": "prestateTracer",
+	"tracerConfig": map[string]interface{}{
+		"diffMode": true,
+	},
+}
+
+// StorageDiff counts the storage slots of a contract changed by txs. A slot
+// changed by several txs is counted once per tx.
+type StorageDiff struct {
+	// Created slots were zero before the tx
+	Created int `json:"created"`
+	// Modified slots went from a value to another
+	Modified int `json:"modified"`
+	// Deleted slots were cleared by the tx
+	Deleted int `json:"deleted"`
+}
+
+// Add adds the slots counted in [other]
+func (d *StorageDiff) Add(other StorageDiff) {
+	d.Created += other.Created
+	d.Modified += other.Modified
+	d.Deleted += other.Deleted
+}
+
+// Growth is the number of slots the state grew by
+func (d StorageDiff) Growth() int {
+	return d.Created - d.Deleted
+}
+
+// BlockStateDiff is the storage changed by the txs of a block, per contract
+type BlockStateDiff struct {
+	Number    uint64                         `json:"number"`
+	TxCount   int                            `json:"txCount"`
+	Contracts map[common.Address]StorageDiff `json:"contracts"`
+}
+
+// Total sums the storage changed in every contract
+func (b BlockStateDiff) Total() StorageDiff {
+	var total StorageDiff
+	for _, diff := range b.Contracts {
+		total.Add(diff)
+	}
+	return total
+}
+
+// prestateDiff is the result of the prestate tracer in diff mode. [Pre] holds
+// the former value of every changed slot that wasn't zero, and [Post] the new
+// value of every changed slot that isn't zero.
+type prestateDiff struct {
+	Pre  map[common.Address]accountStorage `json:"pre"`
+	Post map[common.Address]accountStorage `json:"post"`
+}
+
+type accountStorage struct {
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// txTrace is the trace of a tx returned by debug_traceBlockByNumber
+type txTrace struct {
+	TxHash common.Hash   `json:"txHash"`
+	Result *prestateDiff `json:"result"`
+	Error  string        `json:"error"`
+}
+
+// TraceStateDiff traces the txs of block [number] and returns the storage they
+// changed. The node must serve the debug APIs and have kept the state the
+// block was built on.
+func TraceStateDiff(ctx context.Context, client *rpc.Client, number uint64) (BlockStateDiff, error) {
+	var traces []txTrace
+	if err := client.CallContext(ctx, &traces, "debug_traceBlockByNumber", hexutil.EncodeUint64(number), prestateDiffTracer); err != nil {
+		return BlockStateDiff{}, fmt.Errorf("could not trace block %d: %w", number, err)
+	}
+
+	diff := BlockStateDiff{
+		Number:    number,
+		TxCount:   len(traces),
+		Contracts: make(map[common.Address]StorageDiff),
+	}
+	for _, trace := range traces {
+		if len(trace.Error) > 0 || trace.Result == nil {
+			return BlockStateDiff{}, fmt.Errorf("could not trace tx %s of block %d: %s", trace.TxHash.Hex(), number, trace.Error)
+		}
+		for address, storage := range trace.Result.storageDiffs() {
+			contract := diff.Contracts[address]
+			contract.Add(storage)
+			diff.Contracts[address] = contract
+		}
+	}
+	return diff, nil
+}
+
+// storageDiffs counts the slots the tx changed in every contract
+func (p *prestateDiff) storageDiffs() map[common.Address]StorageDiff {
+	diffs := make(map[common.Address]StorageDiff)
+	for address, pre := range p.Pre {
+		post := p.Post[address].Storage
+		var diff StorageDiff
+		for slot := range pre.Storage {
+			if _, ok := post[slot]; ok {
+				diff.Modified++
+			} else {
+				diff.Deleted++
+			}
+		}
+		if diff != (StorageDiff{}) {
+			diffs[address] = diff
+		}
+	}
+	for address, post := range p.Post {
+		pre := p.Pre[address].Storage
+		diff := diffs[address]
+		for slot := range post.Storage {
+			if _, ok := pre[slot]; !ok {
+				diff.Created++
+			}
+		}
+		if diff != (StorageDiff{}) {
+			diffs[address] = diff
+		}
+	}
+	return diffs
+}
+
+// SortContracts returns the addresses of [contracts] by decreasing growth, and
+// then by decreasing number of changed slots
+func SortContracts(contracts map[common.Address]StorageDiff) []common.Address {
+	addresses := make([]common.Address, 0, len(contracts))
+	for address := range contracts {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		a, b := contracts[addresses[i]], contracts[addresses[j]]
+		if a.Growth() != b.Growth() {
+			return a.Growth() > b.Growth()
+		}
+		if changedA, changedB := a.Created+a.Modified+a.Deleted, b.Created+b.Modified+b.Deleted; changedA != changedB {
+			return changedA > changedB
+		}
+		return addresses[i].Hex() < addresses[j].Hex()
+	})
+	return addresses
+}
@@ -0,0 +1,89 @@
+package evm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+)
+
+func TestStorageDiffs(t *testing.T) {
+	var (
+		a = common.HexToAddress("0xa")
+		b = common.HexToAddress("0xb")
+
+		slot1 = common.HexToHash("0x1")
+		slot2 = common.HexToHash("0x2")
+		slot3 = common.HexToHash("0x3")
+
+		one = common.HexToHash("0x1")
+		two = common.HexToHash("0x2")
+	)
+	storage := func(slots map[common.Hash]common.Hash) accountStorage {
+		return accountStorage{Storage: slots}
+	}
+	tests := []struct {
+		name string
+		diff prestateDiff
+		want map[common.Address]StorageDiff
+	}{
+		{
+			name: "no changes",
+			want: map[common.Address]StorageDiff{},
+		},
+		{
+			name: "created",
+			diff: prestateDiff{
+				Post: map[common.Address]accountStorage{a: storage(map[common.Hash]common.Hash{slot1: one, slot2: two})},
+			},
+			want: map[common.Address]StorageDiff{a: {Created: 2}},
+		},
+		{
+			name: "modified",
+			diff: prestateDiff{
+				Pre:  map[common.Address]accountStorage{a: storage(map[common.Hash]common.Hash{slot1: one})},
+				Post: map[common.Address]accountStorage{a: storage(map[common.Hash]common.Hash{slot1: two})},
+			},
+			want: map[common.Address]StorageDiff{a: {Modified: 1}},
+		},
+		{
+			name: "deleted",
+			diff: prestateDiff{
+				Pre: map[common.Address]accountStorage{a: storage(map[common.Hash]common.Hash{slot1: one})},
+			},
+			want: map[common.Address]StorageDiff{a: {Deleted: 1}},
+		},
+		{
+			name: "mixed",
+			diff: prestateDiff{
+				Pre: map[common.Address]accountStorage{
+					a: storage(map[common.Hash]common.Hash{slot1: one, slot2: two}),
+					b: storage(map[common.Hash]common.Hash{slot1: one}),
+				},
+				Post: map[common.Address]accountStorage{
+					a: storage(map[common.Hash]common.Hash{slot1: two, slot3: one}),
+					b: storage(map[common.Hash]common.Hash{slot1: two, slot2: one}),
+				},
+			},
+			want: map[common.Address]StorageDiff{
+				a: {Created: 1, Modified: 1, Deleted: 1},
+				b: {Created: 1, Modified: 1},
+			},
+		},
+		{
+			name: "balance change only",
+			diff: prestateDiff{
+				Pre:  map[common.Address]accountStorage{a: {}},
+				Post: map[common.Address]accountStorage{a: {}},
+			},
+			want: map[common.Address]StorageDiff{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.diff.storageDiffs(); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
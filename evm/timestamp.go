@@ -0,0 +1,40 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/libevm/rpc"
+)
+
+// BlockAtTime returns the first block accepted at or after [t], binary
+// searching the chain up to its last accepted block
+func BlockAtTime(ctx context.Context, client *rpc.Client, t time.Time) (Block, error) {
+	last, err := BlockByNumber(ctx, client, nil)
+	if err != nil {
+		return Block{}, err
+	}
+	if last.Time.Before(t) {
+		return Block{}, fmt.Errorf("%w: last accepted block %d is from %s", errBlockNotFound, last.Number, last.Time.UTC().Format(time.RFC3339Nano))
+	}
+
+	// [found] is block [high], which is always at or after [t]
+	found := last
+	low, high := uint64(0), last.Number
+	for low < high {
+		mid := low + (high-low)/2
+		block, err := BlockByNumber(ctx, client, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return Block{}, err
+		}
+		if block.Time.Before(t) {
+			low = mid + 1
+		} else {
+			high = mid
+			found = block
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,83 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/rpc"
+)
+
+// fakeChain serves eth_getBlockByNumber for blocks accepted at [times], in
+// milliseconds
+type fakeChain struct {
+	times []uint64
+}
+
+func (c *fakeChain) GetBlockByNumber(number rpc.BlockNumber, _ bool) (map[string]interface{}, error) {
+	n := int64(number)
+	if number == rpc.LatestBlockNumber {
+		n = int64(len(c.times) - 1)
+	}
+	if n < 0 || n >= int64(len(c.times)) {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"number":                hexutil.Uint64(n),
+		"timestamp":             hexutil.Uint64(c.times[n] / 1000),
+		"timestampMilliseconds": hexutil.Uint64(c.times[n]),
+		"transactions":          []interface{}{},
+	}, nil
+}
+
+// dialFakeChain returns a client of a chain whose blocks are accepted at
+// [times], in milliseconds
+func dialFakeChain(t *testing.T, times []uint64) *rpc.Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &fakeChain{times: times}); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	return client
+}
+
+func TestBlockAtTime(t *testing.T) {
+	times := []uint64{1_000, 3_000, 3_000, 3_500, 9_000}
+	tests := []struct {
+		name      string
+		timeMs    int64
+		want      uint64
+		wantErrIs error
+	}{
+		{name: "before genesis", timeMs: 0, want: 0},
+		{name: "at genesis", timeMs: 1_000, want: 0},
+		{name: "first of blocks at the same time", timeMs: 3_000, want: 1},
+		{name: "between blocks", timeMs: 3_001, want: 3},
+		{name: "at the last block", timeMs: 9_000, want: 4},
+		{name: "after the last block", timeMs: 9_001, wantErrIs: errBlockNotFound},
+	}
+	client := dialFakeChain(t, times)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			block, err := BlockAtTime(context.Background(), client, time.UnixMilli(test.timeMs))
+			if !errors.Is(err, test.wantErrIs) {
+				t.Fatalf("got error %v, want %v", err, test.wantErrIs)
+			}
+			if err != nil {
+				return
+			}
+			if block.Number != test.want {
+				t.Fatalf("got block %d, want %d", block.Number, test.want)
+			}
+			if wantTime := time.UnixMilli(int64(times[test.want])); !block.Time.Equal(wantTime) {
+				t.Fatalf("got time %s, want %s", block.Time, wantTime)
+			}
+		})
+	}
+}
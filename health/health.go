@@ -0,0 +1,188 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/events"
+	"github.com/ava-labs/ava-sim/manager"
+
+	apihealth "github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/api/info"
+)
+
+// Types of the events recorded by the background check
+const (
+	EventNodeUnhealthy = "node-unhealthy"
+	EventNodeHealthy   = "node-healthy"
+)
+
+// NodeHealth sums up the health of a node
+type NodeHealth struct {
+	Node    int    `json:"node"`
+	NodeID  string `json:"nodeID"`
+	Healthy bool   `json:"healthy"`
+	// Error is set when the health API of the node couldn't be reached
+	Error string `json:"error,omitempty"`
+	// Failing maps the checks that fail, per chain ones being named after the
+	// alias of the chain, to their error
+	Failing map[string]string `json:"failing,omitempty"`
+}
+
+// FailingChecks returns the names of the failing checks in order
+func (h NodeHealth) FailingChecks() []string {
+	checks := make([]string, 0, len(h.Failing))
+	for check := range h.Failing {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+	return checks
+}
+
+// Summary returns the failing checks of the node, or the reason its health
+// API couldn't be reached
+func (h NodeHealth) Summary() string {
+	if len(h.Error) > 0 {
+		return fmt.Sprintf("unreachable: %s", h.Error)
+	}
+	return strings.Join(h.FailingChecks(), ", ")
+}
+
+// Checker queries the health API of every node
+type Checker struct {
+	nodes []manager.NodeInfo
+	log   *events.Log
+
+	lock sync.Mutex
+	// aliases maps the IDs of the primary network chains, which per chain
+	// checks are named after, to their alias
+	aliases map[string]string
+	// last is the result of the last background check
+	last []NodeHealth
+}
+
+// NewChecker creates a checker of [nodes], recording in [log] when they become
+// unhealthy or healthy again
+func NewChecker(nodes []manager.NodeInfo, log *events.Log) *Checker {
+	return &Checker{
+		nodes: nodes,
+		log:   log,
+	}
+}
+
+// Check returns the health of every node
+func (c *Checker) Check(ctx context.Context) []NodeHealth {
+	aliases := c.chainAliases(ctx)
+
+	report := make([]NodeHealth, len(c.nodes))
+	var wg sync.WaitGroup
+	for i, node := range c.nodes {
+		wg.Add(1)
+		go func(i int, node manager.NodeInfo) {
+			defer wg.Done()
+			report[i] = checkNode(ctx, i+1, node, aliases)
+		}(i, node)
+	}
+	wg.Wait()
+	return report
+}
+
+// Run checks the nodes every [interval] until [ctx] is cancelled and records
+// every node whose health or failing checks changed
+func (c *Checker) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		report := c.Check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		c.record(report)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (c *Checker) record(report []NodeHealth) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i, h := range report {
+		// Nodes start out healthy, so that only degradations are reported
+		previous := NodeHealth{Healthy: true}
+		if c.last != nil {
+			previous = c.last[i]
+		}
+		switch {
+		case !h.Healthy && (previous.Healthy || h.Summary() != previous.Summary()):
+			c.log.Record(EventNodeUnhealthy, "node%d is unhealthy: %s", h.Node, h.Summary())
+		case h.Healthy && !previous.Healthy:
+			c.log.Record(EventNodeHealthy, "node%d is healthy again", h.Node)
+		}
+	}
+	c.last = report
+}
+
+// chainAliases returns the aliases of the primary network chains, looking them
+// up on the first node that answers
+func (c *Checker) chainAliases(ctx context.Context) map[string]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.aliases != nil {
+		return c.aliases
+	}
+	for _, node := range c.nodes {
+		client := info.NewClient(node.URI)
+		aliases := make(map[string]string)
+		for _, alias := range constants.Chains {
+			id, err := client.GetBlockchainID(ctx, alias)
+			if err != nil {
+				break
+			}
+			aliases[id.String()] = alias
+		}
+		if len(aliases) == len(constants.Chains) {
+			c.aliases = aliases
+			return aliases
+		}
+	}
+	return nil
+}
+
+func checkNode(ctx context.Context, nodeNum int, node manager.NodeInfo, aliases map[string]string) NodeHealth {
+	h := NodeHealth{
+		Node:   nodeNum,
+		NodeID: node.ID,
+	}
+	reply, err := apihealth.NewClient(node.URI).Health(ctx, nil)
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	h.Healthy = reply.Healthy
+	for name, result := range reply.Checks {
+		if result.Error == nil {
+			continue
+		}
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+		if h.Failing == nil {
+			h.Failing = make(map[string]string)
+		}
+		h.Failing[name] = *result.Error
+	}
+	return h
+}
@@ -0,0 +1,388 @@
+package load
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/ava-labs/avalanchego/genesis"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/status"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	ethcommon "github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/ethclient"
+)
+
+// benchPollFrequency is how often every node is asked whether it accepted the
+// tx being benchmarked, which bounds the precision of the latencies
+const benchPollFrequency = 10 * time.Millisecond
+
+// HistogramBounds are the upper bounds, in milliseconds, of the buckets of the
+// latency histograms. The last bucket counts the latencies above them all.
+var HistogramBounds = []float64{50, 100, 200, 300, 500, 750, 1000, 1500, 2000, 3000, 5000}
+
+// BenchChain issues the txs of a benchmark and tells whether a node accepted
+// them
+type BenchChain interface {
+	// Issue issues a tx and returns its ID
+	Issue(ctx context.Context) (string, error)
+	// Accepted reports whether node [node], starting at 0, accepted tx [id]
+	Accepted(ctx context.Context, node int, id string) (bool, error)
+	Close()
+}
+
+// BenchConfig configures a finality benchmark
+type BenchConfig struct {
+	// Txs is the number of txs issued, one after the other
+	Txs int
+	// Interval is the time between a tx being accepted by every node and the
+	// next one being issued
+	Interval time.Duration
+	// Timeout is how long a tx is waited for on every node
+	Timeout time.Duration
+	// Down lists the nodes, starting at 0, that aren't running and are left
+	// out of the benchmark
+	Down []int
+}
+
+// BenchReport holds the latencies from submission to acceptance measured by a
+// benchmark
+type BenchReport struct {
+	Txs int `json:"txs"`
+	// Failed counts the txs that couldn't be issued
+	Failed int `json:"failed"`
+	// Nodes holds the latencies of every node, starting at node1. The report
+	// of a node left out is empty.
+	Nodes []LatencyReport `json:"nodes"`
+	// Down lists the nodes, starting at 1, left out as they weren't running
+	Down []int `json:"down,omitempty"`
+	// All holds the latencies of every node together
+	All LatencyReport `json:"all"`
+	// Finality holds the time until every node accepted a tx
+	Finality LatencyReport `json:"finality"`
+}
+
+// LatencyReport sums up latencies
+type LatencyReport struct {
+	Accepted int `json:"accepted"`
+	// TimedOut counts the txs that weren't accepted before the timeout
+	TimedOut  int     `json:"timedOut"`
+	Latency   Latency `json:"latency"`
+	MeanMS    float64 `json:"meanMs"`
+	Histogram []int   `json:"histogram"`
+}
+
+func newLatencyReport(latencies []time.Duration, timedOut int) LatencyReport {
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	r := LatencyReport{
+		Accepted: len(sorted),
+		TimedOut: timedOut,
+		Latency: Latency{
+			P50: Percentile(sorted, 50),
+			P90: Percentile(sorted, 90),
+			P99: Percentile(sorted, 99),
+			Max: Percentile(sorted, 100),
+		},
+		Histogram: make([]int, len(HistogramBounds)+1),
+	}
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+		ms := float64(latency.Microseconds()) / 1000
+		bucket := sort.SearchFloat64s(HistogramBounds, ms)
+		r.Histogram[bucket]++
+	}
+	if len(sorted) > 0 {
+		r.MeanMS = float64((total / time.Duration(len(sorted))).Microseconds()) / 1000
+	}
+	return r
+}
+
+// Benchmark issues txs on [chain] one at a time and measures how long each of
+// the [nodes] nodes, but those in [BenchConfig.Down], takes to accept them.
+// [progress] is called with the index of every tx once it is done with.
+func Benchmark(ctx context.Context, chain BenchChain, nodes int, config BenchConfig, progress func(int)) BenchReport {
+	var (
+		report    = BenchReport{Txs: config.Txs}
+		latencies = make([][]time.Duration, nodes)
+		timedOut  = make([]int, nodes)
+		finality  []time.Duration
+		unfinal   int
+		running   = make([]bool, nodes)
+	)
+	for node := range running {
+		running[node] = true
+	}
+	for _, node := range config.Down {
+		running[node] = false
+		report.Down = append(report.Down, node+1)
+	}
+	for i := 0; i < config.Txs && ctx.Err() == nil; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(config.Interval):
+			case <-ctx.Done():
+			}
+		}
+		start := time.Now()
+		id, err := chain.Issue(ctx)
+		if err != nil {
+			report.Failed++
+			progress(i)
+			continue
+		}
+
+		txLatencies := waitAccepted(ctx, chain, running, id, start, config.Timeout)
+		var slowest time.Duration
+		final := true
+		for node, latency := range txLatencies {
+			if !running[node] {
+				continue
+			}
+			if latency < 0 {
+				timedOut[node]++
+				final = false
+				continue
+			}
+			latencies[node] = append(latencies[node], latency)
+			if latency > slowest {
+				slowest = latency
+			}
+		}
+		if final {
+			finality = append(finality, slowest)
+		} else {
+			unfinal++
+		}
+		progress(i)
+	}
+
+	var (
+		all         []time.Duration
+		allTimedOut int
+	)
+	for node := range latencies {
+		report.Nodes = append(report.Nodes, newLatencyReport(latencies[node], timedOut[node]))
+		all = append(all, latencies[node]...)
+		allTimedOut += timedOut[node]
+	}
+	report.All = newLatencyReport(all, allTimedOut)
+	report.Finality = newLatencyReport(finality, unfinal)
+	return report
+}
+
+// waitAccepted returns how long after [start] every [running] node accepted tx
+// [id], or -1 for the nodes that didn't before [timeout]. The nodes that aren't
+// running aren't polled.
+func waitAccepted(ctx context.Context, chain BenchChain, running []bool, id string, start time.Time, timeout time.Duration) []time.Duration {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	latencies := make([]time.Duration, len(running))
+	var wg sync.WaitGroup
+	for node := range latencies {
+		if !running[node] {
+			continue
+		}
+		wg.Add(1)
+		go func(node int) {
+			defer wg.Done()
+			ticker := time.NewTicker(benchPollFrequency)
+			defer ticker.Stop()
+			for {
+				if accepted, err := chain.Accepted(ctx, node, id); err == nil && accepted {
+					latencies[node] = time.Since(start)
+					return
+				}
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					latencies[node] = -1
+					return
+				}
+			}
+		}(node)
+	}
+	wg.Wait()
+	return latencies
+}
+
+// evmBenchChain issues transfers from the funded key to itself
+type evmBenchChain struct {
+	issuer  *ethclient.Client
+	nodes   []*ethclient.Client
+	chainID *big.Int
+	nonce   uint64
+}
+
+// NewEVMBenchChain benchmarks the EVM chain served at [issuerURL], which txs
+// are issued to, and at [nodeURLs]
+func NewEVMBenchChain(ctx context.Context, issuerURL string, nodeURLs []string) (BenchChain, error) {
+	c := &evmBenchChain{}
+	var err error
+	if c.issuer, err = ethclient.DialContext(ctx, issuerURL); err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", issuerURL, err)
+	}
+	for _, url := range nodeURLs {
+		client, err := ethclient.DialContext(ctx, url)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("could not connect to %s: %w", url, err)
+		}
+		c.nodes = append(c.nodes, client)
+	}
+	if c.chainID, err = c.issuer.ChainID(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("could not get chain ID: %w", err)
+	}
+	if c.nonce, err = c.issuer.PendingNonceAt(ctx, evm.Address(evm.FundedKey())); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("could not get nonce: %w", err)
+	}
+	return c, nil
+}
+
+func (c *evmBenchChain) Issue(ctx context.Context) (string, error) {
+	fees, err := evm.SuggestFees(ctx, c.issuer)
+	if err != nil {
+		return "", err
+	}
+	key := evm.FundedKey()
+	to := evm.Address(key)
+	tx, err := evm.SignTx(key, c.chainID, c.nonce, &to, big.NewInt(1), evm.TransferGas, nil, fees)
+	if err != nil {
+		return "", err
+	}
+	if err := c.issuer.SendTransaction(ctx, tx); err != nil {
+		// The nonce may or may not have been used up
+		if nonce, nonceErr := c.issuer.PendingNonceAt(ctx, to); nonceErr == nil {
+			c.nonce = nonce
+		}
+		return "", err
+	}
+	c.nonce++
+	return tx.Hash().Hex(), nil
+}
+
+func (c *evmBenchChain) Accepted(ctx context.Context, node int, id string) (bool, error) {
+	receipt, err := c.nodes[node].TransactionReceipt(ctx, ethcommon.HexToHash(id))
+	if err != nil {
+		return false, err
+	}
+	return receipt.BlockHash != (ethcommon.Hash{}), nil
+}
+
+func (c *evmBenchChain) Close() {
+	if c.issuer != nil {
+		c.issuer.Close()
+	}
+	for _, client := range c.nodes {
+		client.Close()
+	}
+}
+
+// primaryBenchChain issues base txs from the funded key to itself on the P or
+// X chain
+type primaryBenchChain struct {
+	chain  string
+	wallet *primary.Wallet
+	owner  secp256k1fx.OutputOwners
+	nodeP  []*platformvm.Client
+	nodeX  []*avm.Client
+}
+
+// NewPrimaryBenchChain benchmarks [chain], P or X, whose txs are issued to the
+// node at [issuerURI] and whose acceptance is checked on the nodes at
+// [nodeURIs]
+func NewPrimaryBenchChain(ctx context.Context, chain string, issuerURI string, nodeURIs []string) (BenchChain, error) {
+	if chain != "P" && chain != "X" {
+		return nil, fmt.Errorf("unknown primary network chain %q", chain)
+	}
+	kc := secp256k1fx.NewKeychain(genesis.EWOQKey)
+	w, err := primary.MakeWallet(ctx, issuerURI, kc, kc, primary.WalletConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("could not create wallet: %w", err)
+	}
+	c := &primaryBenchChain{
+		chain:  chain,
+		wallet: w,
+		owner: secp256k1fx.OutputOwners{
+			Threshold: 1,
+			Addrs:     []ids.ShortID{genesis.EWOQKey.PublicKey().Address()},
+		},
+	}
+	for _, uri := range nodeURIs {
+		if chain == "P" {
+			c.nodeP = append(c.nodeP, platformvm.NewClient(uri))
+		} else {
+			c.nodeX = append(c.nodeX, avm.NewClient(uri, "X"))
+		}
+	}
+	return c, nil
+}
+
+func (c *primaryBenchChain) Issue(ctx context.Context) (string, error) {
+	// Acceptance is measured on every node rather than awaited by the wallet
+	options := []common.Option{common.WithContext(ctx), common.WithAssumeDecided()}
+	if c.chain == "P" {
+		assetID := c.wallet.P().Builder().Context().AVAXAssetID
+		tx, err := c.wallet.P().IssueBaseTx([]*avax.TransferableOutput{c.output(assetID)}, options...)
+		if err != nil {
+			return "", err
+		}
+		return tx.ID().String(), nil
+	}
+	assetID := c.wallet.X().Builder().Context().AVAXAssetID
+	tx, err := c.wallet.X().IssueBaseTx([]*avax.TransferableOutput{c.output(assetID)}, options...)
+	if err != nil {
+		return "", err
+	}
+	return tx.ID().String(), nil
+}
+
+func (c *primaryBenchChain) output(assetID ids.ID) *avax.TransferableOutput {
+	return &avax.TransferableOutput{
+		Asset: avax.Asset{ID: assetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt:          1,
+			OutputOwners: c.owner,
+		},
+	}
+}
+
+func (c *primaryBenchChain) Accepted(ctx context.Context, node int, id string) (bool, error) {
+	txID, err := ids.FromString(id)
+	if err != nil {
+		return false, err
+	}
+	if c.chain == "P" {
+		txStatus, err := c.nodeP[node].GetTxStatus(ctx, txID)
+		if err != nil {
+			return false, err
+		}
+		return txStatus.Status == status.Committed, nil
+	}
+	txStatus, err := c.nodeX[node].GetTxStatus(ctx, txID)
+	if err != nil {
+		return false, err
+	}
+	return txStatus == choices.Accepted, nil
+}
+
+func (*primaryBenchChain) Close() {}
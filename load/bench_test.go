@@ -0,0 +1,79 @@
+package load
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewLatencyReport(t *testing.T) {
+	ms := func(values ...float64) []time.Duration {
+		durations := make([]time.Duration, len(values))
+		for i, v := range values {
+			durations[i] = time.Duration(v * float64(time.Millisecond))
+		}
+		return durations
+	}
+	// histogram returns the counts of the buckets with [counts] set by index
+	histogram := func(counts map[int]int) []int {
+		h := make([]int, len(HistogramBounds)+1)
+		for bucket, count := range counts {
+			h[bucket] = count
+		}
+		return h
+	}
+	tests := []struct {
+		name      string
+		latencies []time.Duration
+		timedOut  int
+		want      LatencyReport
+	}{
+		{
+			name:     "no latencies",
+			timedOut: 2,
+			want: LatencyReport{
+				TimedOut:  2,
+				Histogram: histogram(nil),
+			},
+		},
+		{
+			name:      "bounds are inclusive",
+			latencies: ms(50, 50.001, 100, 5000),
+			want: LatencyReport{
+				Accepted:  4,
+				Latency:   Latency{P50: 50.001, P90: 5000, P99: 5000, Max: 5000},
+				MeanMS:    1300.000,
+				Histogram: histogram(map[int]int{0: 1, 1: 2, 10: 1}),
+			},
+		},
+		{
+			name:      "above every bound",
+			latencies: ms(5000.001, 9000),
+			want: LatencyReport{
+				Accepted:  2,
+				Latency:   Latency{P50: 5000.001, P90: 9000, P99: 9000, Max: 9000},
+				MeanMS:    7000,
+				Histogram: histogram(map[int]int{11: 2}),
+			},
+		},
+		{
+			name:      "unsorted",
+			latencies: ms(700, 10, 250, 1200),
+			timedOut:  1,
+			want: LatencyReport{
+				Accepted:  4,
+				TimedOut:  1,
+				Latency:   Latency{P50: 250, P90: 1200, P99: 1200, Max: 1200},
+				MeanMS:    540,
+				Histogram: histogram(map[int]int{0: 1, 3: 1, 5: 1, 7: 1}),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := newLatencyReport(test.latencies, test.timedOut); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package load
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/ava-labs/libevm/ethclient"
+	"github.com/fatih/color"
+)
+
+// feesRefreshFrequency is how often the fees offered by load txs follow the
+// base fee
+const feesRefreshFrequency = time.Second
+
+// EVMConfig configures the load generated on an EVM chain
+type EVMConfig struct {
+	RPCURL string
+	// WSURL, if set, is used to be notified of accepted blocks, which are
+	// polled for otherwise
+	WSURL string
+	// Accounts is the number of accounts txs are issued from
+	Accounts int
+	// Funder funds the accounts holding less than [Funding]
+	Funder  *ecdsa.PrivateKey
+	Funding *big.Int
+}
+
+// account is an account load txs are issued from. It is used by a single
+// issuer at a time, which keeps track of its nonce.
+type account struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+	nonce   uint64
+	// to receives the transfers of the account
+	to common.Address
+}
+
+// EVM issues transfers between generated accounts of an EVM chain
+type EVM struct {
+	config  EVMConfig
+	client  *ethclient.Client
+	chainID *big.Int
+	stats   *Stats
+	// accounts holds the accounts not in use
+	accounts chan *account
+
+	lock sync.RWMutex
+	fees evm.Fees
+}
+
+// NewEVM connects to the chain and funds the accounts of the load test, whose
+// txs are recorded in [stats]. The accounts are derived from their index, so
+// that they are funded once per chain.
+func NewEVM(ctx context.Context, config EVMConfig, stats *Stats) (*EVM, error) {
+	client, err := ethclient.DialContext(ctx, config.RPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", config.RPCURL, err)
+	}
+	e := &EVM{
+		config:   config,
+		client:   client,
+		stats:    stats,
+		accounts: make(chan *account, config.Accounts),
+	}
+	if err := e.init(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *EVM) init(ctx context.Context) error {
+	var err error
+	if e.chainID, err = e.client.ChainID(ctx); err != nil {
+		return fmt.Errorf("could not get chain ID: %w", err)
+	}
+	if e.fees, err = evm.SuggestFees(ctx, e.client); err != nil {
+		return err
+	}
+
+	accounts := make([]*account, e.config.Accounts)
+	for i := range accounts {
+		key, err := crypto.ToECDSA(crypto.Keccak256([]byte(fmt.Sprintf("ava-sim load %d", i))))
+		if err != nil {
+			return err
+		}
+		accounts[i] = &account{
+			key:     key,
+			address: evm.Address(key),
+		}
+	}
+	for i, a := range accounts {
+		a.to = accounts[(i+1)%len(accounts)].address
+	}
+	if err := e.fund(ctx, accounts); err != nil {
+		return err
+	}
+	for _, a := range accounts {
+		if a.nonce, err = e.client.PendingNonceAt(ctx, a.address); err != nil {
+			return fmt.Errorf("could not get nonce of %s: %w", a.address, err)
+		}
+		e.accounts <- a
+	}
+	return nil
+}
+
+// fund sends [EVMConfig.Funding] to the accounts holding less than that
+func (e *EVM) fund(ctx context.Context, accounts []*account) error {
+	addresses := make([]common.Address, len(accounts))
+	for i, a := range accounts {
+		addresses[i] = a.address
+	}
+	return fundAccounts(ctx, e.client, e.chainID, e.fees, e.config.Funder, e.config.Funding, addresses)
+}
+
+// fundAccounts sends [funding] from [funder] to the [addresses] holding less
+// than that, and waits for the transfers to be accepted
+func fundAccounts(
+	ctx context.Context,
+	client *ethclient.Client,
+	chainID *big.Int,
+	fees evm.Fees,
+	funder *ecdsa.PrivateKey,
+	funding *big.Int,
+	addresses []common.Address,
+) error {
+	funderAddress := evm.Address(funder)
+	nonce, err := client.PendingNonceAt(ctx, funderAddress)
+	if err != nil {
+		return fmt.Errorf("could not get nonce of funder %s: %w", funderAddress, err)
+	}
+	var txs []*types.Transaction
+	for _, address := range addresses {
+		balance, err := client.BalanceAt(ctx, address, nil)
+		if err != nil {
+			return fmt.Errorf("could not get balance of %s: %w", address, err)
+		}
+		if balance.Cmp(funding) >= 0 {
+			continue
+		}
+		address := address
+		tx, err := evm.SignTx(funder, chainID, nonce, &address, funding, evm.TransferGas, nil, fees)
+		if err != nil {
+			return err
+		}
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			return fmt.Errorf("could not fund %s: %w", address, err)
+		}
+		txs = append(txs, tx)
+		nonce++
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+
+	color.Cyan("funding %d accounts from %s", len(txs), funderAddress)
+	for _, tx := range txs {
+		receipt, err := evm.WaitReceipt(ctx, client, tx.Hash())
+		if err != nil {
+			return fmt.Errorf("could not get receipt of funding tx %s: %w", tx.Hash(), err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return fmt.Errorf("funding tx %s failed", tx.Hash())
+		}
+	}
+	return nil
+}
+
+// Issue sends a transfer from the next available account
+func (e *EVM) Issue(ctx context.Context) error {
+	var a *account
+	select {
+	case a = <-e.accounts:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() {
+		e.accounts <- a
+	}()
+
+	e.lock.RLock()
+	fees := e.fees
+	e.lock.RUnlock()
+	tx, err := evm.SignTx(a.key, e.chainID, a.nonce, &a.to, big.NewInt(1), evm.TransferGas, nil, fees)
+	if err != nil {
+		return err
+	}
+
+	id := tx.Hash().Hex()
+	e.stats.Submitted(id, time.Now())
+	if err := e.client.SendTransaction(ctx, tx); err != nil {
+		e.stats.Dropped(id)
+		// The nonce may or may not have been used up
+		if nonce, nonceErr := e.client.PendingNonceAt(ctx, a.address); nonceErr == nil {
+			a.nonce = nonce
+		}
+		return err
+	}
+	a.nonce++
+	return nil
+}
+
+// Workers returns the number of txs that can be issued at once
+func (e *EVM) Workers() int {
+	return e.config.Accounts
+}
+
+// Watch records the load txs accepted in the blocks of the chain, and keeps
+// the fees offered by new txs above the base fee, until [ctx] is cancelled
+func (e *EVM) Watch(ctx context.Context) error {
+	head, err := evm.BlockByNumber(ctx, e.client.Client(), nil)
+	if err != nil {
+		return fmt.Errorf("could not get the last accepted block: %w", err)
+	}
+	client := e.client
+	if len(e.config.WSURL) > 0 {
+		wsClient, err := ethclient.DialContext(ctx, e.config.WSURL)
+		if err == nil {
+			defer wsClient.Close()
+			client = wsClient
+		}
+	}
+
+	go e.refreshFees(ctx)
+	return evm.StreamBlocks(ctx, client.Client(), head.Number+1, func(block evm.Block) error {
+		now := time.Now()
+		for _, hash := range block.TxHashes {
+			e.stats.Accepted(hash.Hex(), now)
+		}
+		return nil
+	})
+}
+
+func (e *EVM) refreshFees(ctx context.Context) {
+	ticker := time.NewTicker(feesRefreshFrequency)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		fees, err := evm.SuggestFees(ctx, e.client)
+		if err != nil {
+			continue
+		}
+		e.lock.Lock()
+		e.fees = fees
+		e.lock.Unlock()
+	}
+}
+
+func (e *EVM) Close() {
+	e.client.Close()
+}
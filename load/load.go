@@ -0,0 +1,285 @@
+package load
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/proxy"
+)
+
+// issueFrequency is how often the issuer catches up with the rate of the
+// profile
+const issueFrequency = 10 * time.Millisecond
+
+var errInvalidProfile = errors.New("invalid load profile")
+
+// Profile is the rate txs are issued at over time. The rate ramps linearly
+// from [StartRate] to [Rate] over [Ramp], and then stays at [Rate].
+type Profile struct {
+	// Rate is the number of txs issued per second
+	Rate      float64
+	StartRate float64
+	Ramp      time.Duration
+	// Duration is how long txs are issued for, or forever if it is 0
+	Duration time.Duration
+}
+
+// Verify returns an error if the profile can't be run
+func (p Profile) Verify() error {
+	switch {
+	case p.Rate <= 0:
+		return fmt.Errorf("%w: the rate must be positive", errInvalidProfile)
+	case p.StartRate < 0:
+		return fmt.Errorf("%w: the start rate can't be negative", errInvalidProfile)
+	case p.Ramp < 0 || p.Duration < 0:
+		return fmt.Errorf("%w: durations can't be negative", errInvalidProfile)
+	}
+	return nil
+}
+
+// RateAt returns the rate txs are issued at [elapsed] after the start
+func (p Profile) RateAt(elapsed time.Duration) float64 {
+	if elapsed >= p.Ramp {
+		return p.Rate
+	}
+	return p.StartRate + (p.Rate-p.StartRate)*elapsed.Seconds()/p.Ramp.Seconds()
+}
+
+// issuedBy returns the number of txs issued [elapsed] after the start
+func (p Profile) issuedBy(elapsed time.Duration) float64 {
+	ramp := math.Min(elapsed.Seconds(), p.Ramp.Seconds())
+	issued := ramp * (p.StartRate + p.RateAt(elapsed)) / 2
+	if elapsed > p.Ramp {
+		issued += (elapsed - p.Ramp).Seconds() * p.Rate
+	}
+	return issued
+}
+
+// Run calls [issue] at the rate of [profile] until its duration elapsed or
+// [ctx] is cancelled. At most [workers] calls run at once: txs due while they
+// are all busy are skipped and recorded in [stats]. Run returns once every
+// call returned.
+func Run(ctx context.Context, profile Profile, workers int, stats *Stats, issue func(context.Context) error) {
+	if profile.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, profile.Duration)
+		defer cancel()
+	}
+	ticker := time.NewTicker(issueFrequency)
+	defer ticker.Stop()
+
+	var (
+		wg     sync.WaitGroup
+		busy   = make(chan struct{}, workers)
+		start  = stats.begin()
+		issued = 0
+	)
+	defer wg.Wait()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		due := int(profile.issuedBy(time.Since(start)))
+		for ; issued < due; issued++ {
+			select {
+			case busy <- struct{}{}:
+			default:
+				stats.Skipped()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-busy
+					wg.Done()
+				}()
+				if err := issue(ctx); err != nil && ctx.Err() == nil {
+					stats.Failed(err)
+				}
+			}()
+		}
+	}
+}
+
+// Stats records the txs issued by a load test and when they were accepted
+type Stats struct {
+	lock      sync.Mutex
+	start     time.Time
+	submitted int
+	failed    int
+	skipped   int
+	// pending maps the IDs of the txs waiting to be accepted to the time they
+	// were submitted at
+	pending   map[string]time.Time
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		start:   time.Now(),
+		pending: make(map[string]time.Time),
+		errors:  make(map[string]int),
+	}
+}
+
+// begin restarts the clock of the load test, and returns its start time
+func (s *Stats) begin() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.start = time.Now()
+	return s.start
+}
+
+// Submitted records that tx [id] was submitted at [at]. It is called before
+// the tx is sent, so that its acceptance can't be seen first.
+func (s *Stats) Submitted(id string, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.submitted++
+	s.pending[id] = at
+}
+
+// Dropped records that tx [id] couldn't be sent after all
+func (s *Stats) Dropped(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.pending[id]; ok {
+		s.submitted--
+		delete(s.pending, id)
+	}
+}
+
+// Accepted records that tx [id] was accepted at [at]. Txs that weren't
+// submitted by the load test are ignored.
+func (s *Stats) Accepted(id string, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	submittedAt, ok := s.pending[id]
+	if !ok {
+		return
+	}
+	delete(s.pending, id)
+	s.latencies = append(s.latencies, at.Sub(submittedAt))
+}
+
+// Failed records a tx that couldn't be issued
+func (s *Stats) Failed(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.failed++
+	s.errors[err.Error()]++
+}
+
+// Skipped records a tx that wasn't issued as every worker was busy
+func (s *Stats) Skipped() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.skipped++
+}
+
+// Pending returns the number of submitted txs not accepted yet
+func (s *Stats) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.pending)
+}
+
+// Report sums up a load test
+type Report struct {
+	Elapsed   proxy.Duration `json:"elapsed"`
+	Submitted int            `json:"submitted"`
+	Accepted  int            `json:"accepted"`
+	Pending   int            `json:"pending"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped"`
+	// TPS is the number of txs accepted per second
+	TPS     float64 `json:"tps"`
+	Latency Latency `json:"latency"`
+	// Errors counts the errors txs failed with
+	Errors map[string]int `json:"errors,omitempty"`
+}
+
+// Latency holds the percentiles of the time from submission to acceptance, in
+// milliseconds
+type Latency struct {
+	P50 float64 `json:"p50Ms"`
+	P90 float64 `json:"p90Ms"`
+	P99 float64 `json:"p99Ms"`
+	Max float64 `json:"maxMs"`
+}
+
+// Report sums up the txs recorded so far
+func (s *Stats) Report() Report {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	elapsed := time.Since(s.start)
+	r := Report{
+		Elapsed:   proxy.Duration(elapsed),
+		Submitted: s.submitted,
+		Accepted:  len(s.latencies),
+		Pending:   len(s.pending),
+		Failed:    s.failed,
+		Skipped:   s.skipped,
+	}
+	r.TPS = float64(r.Accepted) / elapsed.Seconds()
+	if len(s.errors) > 0 {
+		r.Errors = make(map[string]int, len(s.errors))
+		for err, count := range s.errors {
+			r.Errors[err] = count
+		}
+	}
+
+	latencies := make([]time.Duration, len(s.latencies))
+	copy(latencies, s.latencies)
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	r.Latency = Latency{
+		P50: Percentile(latencies, 50),
+		P90: Percentile(latencies, 90),
+		P99: Percentile(latencies, 99),
+		Max: Percentile(latencies, 100),
+	}
+	return r
+}
+
+// Percentile returns the [p]th percentile of [sorted] by nearest rank, in
+// milliseconds, or 0 if it is empty
+func Percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return float64(sorted[rank].Microseconds()) / 1000
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf(
+		"%s: %d submitted, %d accepted (%.1f tx/s), %d pending, %d failed, %d skipped, latency p50 %.0fms p90 %.0fms p99 %.0fms max %.0fms",
+		time.Duration(r.Elapsed).Truncate(time.Second), r.Submitted, r.Accepted, r.TPS, r.Pending, r.Failed, r.Skipped,
+		r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max,
+	)
+}
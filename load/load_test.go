@@ -0,0 +1,94 @@
+package load
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProfileIssuedBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		elapsed time.Duration
+		want    float64
+	}{
+		{
+			name:    "constant rate at the start",
+			profile: Profile{Rate: 10},
+			elapsed: 0,
+			want:    0,
+		},
+		{
+			name:    "constant rate",
+			profile: Profile{Rate: 10},
+			elapsed: 3 * time.Second,
+			want:    30,
+		},
+		{
+			name:    "during the ramp",
+			profile: Profile{Rate: 20, StartRate: 0, Ramp: 10 * time.Second},
+			elapsed: 5 * time.Second,
+			// The rate went from 0 to 10
+			want: 25,
+		},
+		{
+			name:    "at the end of the ramp",
+			profile: Profile{Rate: 20, StartRate: 10, Ramp: 10 * time.Second},
+			elapsed: 10 * time.Second,
+			want:    150,
+		},
+		{
+			name:    "after the ramp",
+			profile: Profile{Rate: 20, StartRate: 10, Ramp: 10 * time.Second},
+			elapsed: 12 * time.Second,
+			want:    190,
+		},
+		{
+			name:    "ramp down",
+			profile: Profile{Rate: 10, StartRate: 30, Ramp: 4 * time.Second},
+			elapsed: 2 * time.Second,
+			want:    50,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.profile.issuedBy(test.elapsed); math.Abs(got-test.want) > 1e-9 {
+				t.Fatalf("got %f, want %f", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	ms := func(values ...int) []time.Duration {
+		durations := make([]time.Duration, len(values))
+		for i, v := range values {
+			durations[i] = time.Duration(v) * time.Millisecond
+		}
+		return durations
+	}
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   float64
+	}{
+		{name: "empty", sorted: nil, p: 50, want: 0},
+		{name: "single value", sorted: ms(7), p: 99, want: 7},
+		{name: "median of odd count", sorted: ms(1, 2, 3, 4, 5), p: 50, want: 3},
+		{name: "median of even count", sorted: ms(1, 2, 3, 4), p: 50, want: 2},
+		{name: "nearest rank", sorted: ms(10, 20, 30, 40, 50, 60, 70, 80, 90, 100), p: 90, want: 90},
+		{name: "rounded up rank", sorted: ms(10, 20, 30, 40, 50, 60, 70, 80, 90, 100), p: 91, want: 100},
+		{name: "0th is the minimum", sorted: ms(3, 4, 5), p: 0, want: 3},
+		{name: "100th is the maximum", sorted: ms(3, 4, 5), p: 100, want: 5},
+		{name: "sub-millisecond", sorted: []time.Duration{1500 * time.Microsecond}, p: 50, want: 1.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Percentile(test.sorted, test.p); got != test.want {
+				t.Fatalf("got %f, want %f", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package load
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/ava-labs/avalanchego/genesis"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/ethclient"
+	"github.com/fatih/color"
+)
+
+// Kinds of the transfers issued on the primary network. Atomic transfers
+// export funds from a chain and import them on another, which takes two txs.
+const (
+	KindXTransfer = "x-transfer"
+	KindXToP      = "x-to-p"
+	KindPToX      = "p-to-x"
+	KindXToC      = "x-to-c"
+	KindCToX      = "c-to-x"
+)
+
+// Kinds lists every kind of primary network transfer
+var Kinds = []string{KindXTransfer, KindXToP, KindPToX, KindXToC, KindCToX}
+
+const (
+	// primaryPollFrequency is how often the status of an issued tx is polled
+	// until it is accepted
+	primaryPollFrequency = 20 * time.Millisecond
+
+	// weiPerNAVAX converts the nAVAX of atomic txs to the wei of the C-chain
+	weiPerNAVAX = 1_000_000_000
+)
+
+// PrimaryConfig configures the load generated on the primary network
+type PrimaryConfig struct {
+	// URI is the node txs are issued to
+	URI string
+	// Keys is the number of keys txs are issued from
+	Keys int
+	// Amount is the nAVAX sent by every transfer
+	Amount uint64
+	// Funding is the nAVAX every key is funded with on the P, X and C chains
+	// when it holds less
+	Funding uint64
+}
+
+// primaryKey is a key load txs are issued from, with a wallet tracking its
+// UTXOs. It is used by a single issuer at a time.
+type primaryKey struct {
+	key    *secp256k1.PrivateKey
+	wallet *primary.Wallet
+	owner  *secp256k1fx.OutputOwners
+}
+
+// Primary issues transfers on the X-chain and between the P, X and C chains
+// from generated keys
+type Primary struct {
+	config PrimaryConfig
+	// keys holds the keys not in use
+	keys        chan *primaryKey
+	xChainID    ids.ID
+	cChainID    ids.ID
+	avaxAssetID ids.ID
+	// txs numbers the txs issued, which are only known by their ID once
+	// accepted
+	txs uint64
+}
+
+// NewPrimary creates the wallets of the keys of the load test and funds them.
+// The keys are derived from their index, so that they are funded once per
+// network.
+func NewPrimary(ctx context.Context, config PrimaryConfig) (*Primary, error) {
+	keys := make([]*primaryKey, config.Keys)
+	for i := range keys {
+		key, err := secp256k1.ToPrivateKey(hashing.ComputeHash256([]byte(fmt.Sprintf("ava-sim primary load %d", i))))
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = &primaryKey{
+			key: key,
+			owner: &secp256k1fx.OutputOwners{
+				Threshold: 1,
+				Addrs:     []ids.ShortID{key.Address()},
+			},
+		}
+	}
+
+	p := &Primary{
+		config: config,
+		keys:   make(chan *primaryKey, len(keys)),
+	}
+	if err := p.makeWallets(ctx, keys); err != nil {
+		return nil, err
+	}
+	xContext := keys[0].wallet.X().Builder().Context()
+	p.xChainID = xContext.BlockchainID
+	p.avaxAssetID = xContext.AVAXAssetID
+	p.cChainID = keys[0].wallet.C().Builder().Context().BlockchainID
+
+	funded, err := p.fund(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	// The wallets of funded keys are made again to learn about their funds
+	if funded {
+		if err := p.makeWallets(ctx, keys); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range keys {
+		p.keys <- k
+	}
+	return p, nil
+}
+
+func (p *Primary) makeWallets(ctx context.Context, keys []*primaryKey) error {
+	for _, k := range keys {
+		kc := secp256k1fx.NewKeychain(k.key)
+		w, err := primary.MakeWallet(ctx, p.config.URI, kc, kc, primary.WalletConfig{})
+		if err != nil {
+			return fmt.Errorf("could not create wallet of %s: %w", k.key.Address(), err)
+		}
+		k.wallet = w
+	}
+	return nil
+}
+
+// fund sends [PrimaryConfig.Funding] to the keys holding less than that on
+// each chain, and returns whether any key was funded
+func (p *Primary) fund(ctx context.Context, keys []*primaryKey) (bool, error) {
+	var (
+		xOuts, pOuts []*avax.TransferableOutput
+		cAddrs       []*primaryKey
+		funding      = p.config.Funding
+		cFunding     = new(big.Int).Mul(new(big.Int).SetUint64(funding), big.NewInt(weiPerNAVAX))
+	)
+	for _, k := range keys {
+		xBalance, err := k.wallet.X().Builder().GetFTBalance()
+		if err != nil {
+			return false, err
+		}
+		if xBalance[p.avaxAssetID] < funding {
+			xOuts = append(xOuts, p.output(funding, k.owner))
+		}
+		pBalance, err := k.wallet.P().Builder().GetBalance()
+		if err != nil {
+			return false, err
+		}
+		if pBalance[p.avaxAssetID] < funding {
+			pOuts = append(pOuts, p.output(funding, k.owner))
+		}
+		cBalance, err := k.wallet.C().Builder().GetBalance()
+		if err != nil {
+			return false, err
+		}
+		if cBalance.Cmp(cFunding) < 0 {
+			cAddrs = append(cAddrs, k)
+		}
+	}
+	if len(xOuts) == 0 && len(pOuts) == 0 && len(cAddrs) == 0 {
+		return false, nil
+	}
+
+	color.Cyan("funding %d keys on the X-chain, %d on the P-chain and %d on the C-chain", len(xOuts), len(pOuts), len(cAddrs))
+	kc := secp256k1fx.NewKeychain(genesis.EWOQKey)
+	funder, err := primary.MakeWallet(ctx, p.config.URI, kc, kc, primary.WalletConfig{})
+	if err != nil {
+		return false, fmt.Errorf("could not create funding wallet: %w", err)
+	}
+	if len(xOuts) > 0 {
+		if _, err := funder.X().IssueBaseTx(xOuts, common.WithContext(ctx)); err != nil {
+			return false, fmt.Errorf("could not fund keys on the X-chain: %w", err)
+		}
+	}
+	if len(pOuts) > 0 {
+		if _, err := funder.P().IssueBaseTx(pOuts, common.WithContext(ctx)); err != nil {
+			return false, fmt.Errorf("could not fund keys on the P-chain: %w", err)
+		}
+	}
+	if len(cAddrs) > 0 {
+		if err := p.fundC(ctx, cAddrs, cFunding); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// fundC sends [amount] wei to the C-chain address of [keys]
+func (p *Primary) fundC(ctx context.Context, keys []*primaryKey, amount *big.Int) error {
+	client, err := ethclient.DialContext(ctx, evm.RPCURL(p.config.URI, "C"))
+	if err != nil {
+		return fmt.Errorf("could not connect to the C-chain: %w", err)
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get chain ID: %w", err)
+	}
+	fees, err := evm.SuggestFees(ctx, client)
+	if err != nil {
+		return err
+	}
+	funder := evm.FundedKey()
+	nonce, err := client.PendingNonceAt(ctx, evm.Address(funder))
+	if err != nil {
+		return fmt.Errorf("could not get nonce of funder: %w", err)
+	}
+	var txs []*types.Transaction
+	for _, k := range keys {
+		to := k.key.EthAddress()
+		tx, err := evm.SignTx(funder, chainID, nonce, &to, amount, evm.TransferGas, nil, fees)
+		if err != nil {
+			return err
+		}
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			return fmt.Errorf("could not fund %s on the C-chain: %w", to, err)
+		}
+		txs = append(txs, tx)
+		nonce++
+	}
+	for _, tx := range txs {
+		receipt, err := evm.WaitReceipt(ctx, client, tx.Hash())
+		if err != nil {
+			return fmt.Errorf("could not get receipt of funding tx %s: %w", tx.Hash(), err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return fmt.Errorf("funding tx %s failed", tx.Hash())
+		}
+	}
+	return nil
+}
+
+func (p *Primary) output(amount uint64, owner *secp256k1fx.OutputOwners) *avax.TransferableOutput {
+	return &avax.TransferableOutput{
+		Asset: avax.Asset{ID: p.avaxAssetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt:          amount,
+			OutputOwners: *owner,
+		},
+	}
+}
+
+// Workers returns the number of transfers that can be issued at once
+func (p *Primary) Workers() int {
+	return p.config.Keys
+}
+
+// Issuer returns a function issuing a transfer of [kind] from the next
+// available key, whose txs are recorded in [stats] from the time they are
+// issued until the node reports them as accepted
+func (p *Primary) Issuer(kind string, stats *Stats) (func(context.Context) error, error) {
+	var transfer func(context.Context, *primaryKey, *Stats) error
+	switch kind {
+	case KindXTransfer:
+		transfer = p.xTransfer
+	case KindXToP:
+		transfer = p.xToP
+	case KindPToX:
+		transfer = p.pToX
+	case KindXToC:
+		transfer = p.xToC
+	case KindCToX:
+		transfer = p.cToX
+	default:
+		return nil, fmt.Errorf("unknown kind of transfer %q", kind)
+	}
+	return func(ctx context.Context) error {
+		var k *primaryKey
+		select {
+		case k = <-p.keys:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() {
+			p.keys <- k
+		}()
+		return transfer(ctx, k, stats)
+	}, nil
+}
+
+// xTransfer sends [PrimaryConfig.Amount] from the key to itself, as the wallet
+// of a key only learns of the UTXOs of the txs it issues
+func (p *Primary) xTransfer(ctx context.Context, k *primaryKey, stats *Stats) error {
+	return p.track(stats, "X-chain transfer", func() error {
+		_, err := k.wallet.X().IssueBaseTx([]*avax.TransferableOutput{p.output(p.config.Amount, k.owner)}, options(ctx)...)
+		return err
+	})
+}
+
+func (p *Primary) xToP(ctx context.Context, k *primaryKey, stats *Stats) error {
+	err := p.track(stats, "X-chain export", func() error {
+		_, err := k.wallet.X().IssueExportTx(constants.PlatformChainID, []*avax.TransferableOutput{p.output(p.config.Amount, k.owner)}, options(ctx)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return p.track(stats, "P-chain import", func() error {
+		_, err := k.wallet.P().IssueImportTx(p.xChainID, k.owner, options(ctx)...)
+		return err
+	})
+}
+
+func (p *Primary) pToX(ctx context.Context, k *primaryKey, stats *Stats) error {
+	err := p.track(stats, "P-chain export", func() error {
+		_, err := k.wallet.P().IssueExportTx(p.xChainID, []*avax.TransferableOutput{p.output(p.config.Amount, k.owner)}, options(ctx)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return p.track(stats, "X-chain import", func() error {
+		_, err := k.wallet.X().IssueImportTx(constants.PlatformChainID, k.owner, options(ctx)...)
+		return err
+	})
+}
+
+func (p *Primary) xToC(ctx context.Context, k *primaryKey, stats *Stats) error {
+	err := p.track(stats, "X-chain export", func() error {
+		_, err := k.wallet.X().IssueExportTx(p.cChainID, []*avax.TransferableOutput{p.output(p.config.Amount, k.owner)}, options(ctx)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return p.track(stats, "C-chain import", func() error {
+		_, err := k.wallet.C().IssueImportTx(p.xChainID, k.key.EthAddress(), options(ctx)...)
+		return err
+	})
+}
+
+func (p *Primary) cToX(ctx context.Context, k *primaryKey, stats *Stats) error {
+	err := p.track(stats, "C-chain export", func() error {
+		_, err := k.wallet.C().IssueExportTx(p.xChainID, []*secp256k1fx.TransferOutput{{
+			Amt:          p.config.Amount,
+			OutputOwners: *k.owner,
+		}}, options(ctx)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return p.track(stats, "X-chain import", func() error {
+		_, err := k.wallet.X().IssueImportTx(p.cChainID, k.owner, options(ctx)...)
+		return err
+	})
+}
+
+// track records a tx described by [desc] in [stats] while [issue] issues it
+// and waits for it to be accepted
+func (p *Primary) track(stats *Stats, desc string, issue func() error) error {
+	id := fmt.Sprintf("%s %d", desc, atomic.AddUint64(&p.txs, 1))
+	stats.Submitted(id, time.Now())
+	if err := issue(); err != nil {
+		stats.Dropped(id)
+		return fmt.Errorf("%s: %w", desc, err)
+	}
+	stats.Accepted(id, time.Now())
+	return nil
+}
+
+func options(ctx context.Context) []common.Option {
+	return []common.Option{
+		common.WithContext(ctx),
+		common.WithPollFrequency(primaryPollFrequency),
+	}
+}
@@ -0,0 +1,286 @@
+package load
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/ava-sim/evm"
+	"github.com/ava-labs/ava-sim/proxy"
+
+	ethereum "github.com/ava-labs/libevm"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/ava-labs/libevm/ethclient"
+)
+
+// ReplayConfig configures the replay of a recording on an EVM chain
+type ReplayConfig struct {
+	RPCURL string
+	// Keys maps the senders of the recording to the keys replaying their txs.
+	// The other senders are replayed by keys derived from their address.
+	Keys map[common.Address]*ecdsa.PrivateKey
+	// Funder funds the replaying keys holding less than [Funding], unless it
+	// is nil
+	Funder  *ecdsa.PrivateKey
+	Funding *big.Int
+	// Speed scales the pace of the recording: 2 replays it twice as fast, and
+	// 0 sends every tx as soon as the previous one was sent
+	Speed float64
+}
+
+// replayer is the key replaying the txs of a sender of the recording
+type replayer struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+	nonce   uint64
+}
+
+// Replayer sends the txs of a recording in order, re-signed by local keys
+type Replayer struct {
+	config  ReplayConfig
+	client  *ethclient.Client
+	chainID *big.Int
+	txs     []evm.RecordedTx
+	calls   []evm.Call
+	// replayers maps the senders of the recording to their replayer
+	replayers map[common.Address]*replayer
+	// addresses maps the senders of the recording and the contracts they
+	// created to their replayed address. Only contracts created by the txs
+	// themselves are mapped: those created by an internal CREATE can't be
+	// known without tracing the recorded chain.
+	addresses map[common.Address]common.Address
+
+	fees        evm.Fees
+	feesUpdated time.Time
+	sent        []common.Hash
+	report      ReplayReport
+}
+
+// ReplayReport sums up a replay
+type ReplayReport struct {
+	Txs    int `json:"txs"`
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+	// Succeeded, Reverted and Pending count the sent txs by the status of
+	// their receipt
+	Succeeded int `json:"succeeded"`
+	Reverted  int `json:"reverted"`
+	Pending   int `json:"pending"`
+	// MaxLag is the longest a tx was sent after its time in the recording
+	MaxLag  proxy.Duration `json:"maxLag"`
+	Elapsed proxy.Duration `json:"elapsed"`
+	// Errors counts the errors txs failed to be sent with
+	Errors map[string]int `json:"errors,omitempty"`
+}
+
+func (r ReplayReport) String() string {
+	return fmt.Sprintf(
+		"%s: %d/%d sent, %d failed, %d succeeded, %d reverted, %d pending, max lag %s",
+		time.Duration(r.Elapsed).Truncate(time.Millisecond), r.Sent, r.Txs, r.Failed, r.Succeeded, r.Reverted, r.Pending,
+		time.Duration(r.MaxLag).Truncate(time.Millisecond),
+	)
+}
+
+// NewReplayer connects to the chain and funds the keys replaying [txs]
+func NewReplayer(ctx context.Context, config ReplayConfig, txs []evm.RecordedTx) (*Replayer, error) {
+	client, err := ethclient.DialContext(ctx, config.RPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", config.RPCURL, err)
+	}
+	r := &Replayer{
+		config:    config,
+		client:    client,
+		txs:       txs,
+		replayers: make(map[common.Address]*replayer),
+		addresses: make(map[common.Address]common.Address),
+		report: ReplayReport{
+			Txs:    len(txs),
+			Errors: make(map[string]int),
+		},
+	}
+	if err := r.init(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Replayer) init(ctx context.Context) error {
+	var err error
+	if r.chainID, err = r.client.ChainID(ctx); err != nil {
+		return fmt.Errorf("could not get chain ID: %w", err)
+	}
+	if err := r.refreshFees(ctx); err != nil {
+		return err
+	}
+
+	var addresses []common.Address
+	for i, tx := range r.txs {
+		call, err := tx.Call()
+		if err != nil {
+			return fmt.Errorf("tx %d: %w", i, err)
+		}
+		r.calls = append(r.calls, call)
+		if _, ok := r.replayers[call.From]; ok {
+			continue
+		}
+		key, ok := r.config.Keys[call.From]
+		if !ok {
+			key, err = crypto.ToECDSA(crypto.Keccak256([]byte("ava-sim replay " + call.From.Hex())))
+			if err != nil {
+				return err
+			}
+		}
+		rep := &replayer{
+			key:     key,
+			address: evm.Address(key),
+		}
+		r.replayers[call.From] = rep
+		r.addresses[call.From] = rep.address
+		addresses = append(addresses, rep.address)
+	}
+
+	if r.config.Funder != nil {
+		if err := fundAccounts(ctx, r.client, r.chainID, r.fees, r.config.Funder, r.config.Funding, addresses); err != nil {
+			return err
+		}
+	}
+	for _, rep := range r.replayers {
+		if rep.nonce, err = r.client.PendingNonceAt(ctx, rep.address); err != nil {
+			return fmt.Errorf("could not get nonce of %s: %w", rep.address, err)
+		}
+	}
+	return nil
+}
+
+// Senders returns the number of senders of the recording
+func (r *Replayer) Senders() int {
+	return len(r.replayers)
+}
+
+// Run sends the txs of the recording in order, each at its time in the
+// recording scaled by [ReplayConfig.Speed], until they were all sent or [ctx]
+// is cancelled. [progress] is called with the index of every tx once it was
+// sent or failed to be.
+func (r *Replayer) Run(ctx context.Context, progress func(int)) {
+	start := time.Now()
+	defer func() {
+		r.report.Elapsed = proxy.Duration(time.Since(start))
+	}()
+	for i, tx := range r.txs {
+		if r.config.Speed > 0 {
+			due := start.Add(time.Duration(float64(tx.OffsetMs) * float64(time.Millisecond) / r.config.Speed))
+			select {
+			case <-time.After(time.Until(due)):
+			case <-ctx.Done():
+				return
+			}
+			if lag := proxy.Duration(time.Since(due)); lag > r.report.MaxLag {
+				r.report.MaxLag = lag
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+
+		if err := r.send(ctx, r.calls[i]); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.report.Failed++
+			r.report.Errors[err.Error()]++
+		}
+		progress(i)
+	}
+}
+
+// send re-signs [call] with the key replaying its sender, towards the replayed
+// address of its recipient, and sends it
+func (r *Replayer) send(ctx context.Context, call evm.Call) error {
+	if time.Since(r.feesUpdated) >= feesRefreshFrequency {
+		// The previous fees are still offered if the new ones can't be known
+		r.refreshFees(ctx)
+	}
+	rep := r.replayers[call.From]
+	to := call.To
+	if to != nil {
+		if address, ok := r.addresses[*to]; ok {
+			to = &address
+		}
+	}
+	gas := call.Gas
+	if gas == 0 {
+		var err error
+		gas, err = r.client.EstimateGas(ctx, ethereum.CallMsg{
+			From:  rep.address,
+			To:    to,
+			Value: call.Value,
+			Data:  call.Data,
+		})
+		if err != nil {
+			return fmt.Errorf("could not estimate gas: %w", err)
+		}
+	}
+
+	tx, err := evm.SignTx(rep.key, r.chainID, rep.nonce, to, call.Value, gas, call.Data, r.fees)
+	if err != nil {
+		return err
+	}
+	if err := r.client.SendTransaction(ctx, tx); err != nil {
+		// The nonce may or may not have been used up
+		if nonce, nonceErr := r.client.PendingNonceAt(ctx, rep.address); nonceErr == nil {
+			rep.nonce = nonce
+		}
+		return err
+	}
+	if to == nil && call.Nonce != nil {
+		r.addresses[crypto.CreateAddress(call.From, *call.Nonce)] = crypto.CreateAddress(rep.address, rep.nonce)
+	}
+	rep.nonce++
+	r.sent = append(r.sent, tx.Hash())
+	r.report.Sent++
+	return nil
+}
+
+func (r *Replayer) refreshFees(ctx context.Context) error {
+	fees, err := evm.SuggestFees(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	r.fees = fees
+	r.feesUpdated = time.Now()
+	return nil
+}
+
+// Wait waits for the receipts of the sent txs until they are all accepted or
+// [ctx] is cancelled, and returns the report of the replay
+func (r *Replayer) Wait(ctx context.Context) ReplayReport {
+	report := r.report
+	report.Errors = make(map[string]int, len(r.report.Errors))
+	for err, count := range r.report.Errors {
+		report.Errors[err] = count
+	}
+	for _, hash := range r.sent {
+		receipt, err := evm.WaitReceipt(ctx, r.client, hash)
+		switch {
+		case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+			report.Pending++
+		case err != nil:
+			report.Pending++
+			report.Errors[err.Error()]++
+		case receipt.Status == types.ReceiptStatusSuccessful:
+			report.Succeeded++
+		default:
+			report.Reverted++
+		}
+	}
+	return report
+}
+
+func (r *Replayer) Close() {
+	r.client.Close()
+}
@@ -0,0 +1,274 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+const (
+	// pollFrequency is how often new lines are looked for when following logs
+	pollFrequency = 250 * time.Millisecond
+
+	// plainTimeFormat is the timestamp every line of a plain log starts with
+	plainTimeFormat = "[01-02|15:04:05.000]"
+	// jsonTimeFormat is the timestamp of every line of a JSON log
+	jsonTimeFormat = "2006-01-02T15:04:05.000Z0700"
+)
+
+// Line is a line of a node log
+type Line struct {
+	Time time.Time
+	// Node is the node (starting at 1) the line was logged by
+	Node int
+	// Chain is the chain the line was logged for, or main for the node itself
+	Chain string
+	Level logging.Level
+	Text  string
+}
+
+// Filter selects the lines that are read
+type Filter struct {
+	// Nodes are the nodes (starting at 1) to read the logs of (default: all)
+	Nodes []int
+	// Chains are the chains to read the logs of (default: all)
+	Chains []string
+	// Level is the lowest level of the lines to read, [logging.Verbo] for all
+	// of them
+	Level logging.Level
+	// Pattern must match the lines to read, if set
+	Pattern *regexp.Regexp
+}
+
+func (f Filter) matchFile(node int, chain string) bool {
+	if len(f.Nodes) > 0 && !containsInt(f.Nodes, node) {
+		return false
+	}
+	if len(f.Chains) == 0 {
+		return true
+	}
+	for _, c := range f.Chains {
+		if strings.EqualFold(c, chain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchLine(line Line) bool {
+	if line.Level < f.Level {
+		return false
+	}
+	return f.Pattern == nil || f.Pattern.MatchString(line.Text)
+}
+
+// Tailer reads the log files of every node, including the ones created after
+// it started, and merges their lines by time
+type Tailer struct {
+	logDirs []string
+	filter  Filter
+	files   map[string]*logFile
+}
+
+// New creates a tailer of the logs written to [logDirs], the log directory of
+// every node in order
+func New(logDirs []string, filter Filter) *Tailer {
+	return &Tailer{
+		logDirs: logDirs,
+		filter:  filter,
+		files:   make(map[string]*logFile),
+	}
+}
+
+// Read returns the lines written since the last call, merged by time
+func (t *Tailer) Read() ([]Line, error) {
+	for i, dir := range t.logDirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.log"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			if _, ok := t.files[path]; ok {
+				continue
+			}
+			// Rotated logs are named after the log and the time of the
+			// rotation, e.g. main-2021-09-21T10-00-00.000.log
+			chain := strings.TrimSuffix(filepath.Base(path), ".log")
+			if strings.Contains(chain, "-") || !t.filter.matchFile(i+1, chain) {
+				continue
+			}
+			t.files[path] = &logFile{
+				path:  path,
+				node:  i + 1,
+				chain: chain,
+			}
+		}
+	}
+
+	var lines []Line
+	for _, file := range t.files {
+		fileLines, err := file.read()
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range fileLines {
+			if t.filter.matchLine(line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	// Lines of the same file are already in order, which a stable sort keeps
+	// for lines logged at the same time
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time.Before(lines[j].Time)
+	})
+	return lines, nil
+}
+
+// Follow calls [fn] with the lines already written and then with new ones as
+// they are written, until [ctx] is cancelled
+func (t *Tailer) Follow(ctx context.Context, fn func(Line)) error {
+	ticker := time.NewTicker(pollFrequency)
+	defer ticker.Stop()
+	for {
+		lines, err := t.Read()
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			fn(line)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// logFile is a log file read up to [offset]
+type logFile struct {
+	path  string
+	node  int
+	chain string
+
+	offset int64
+	// partial is the end of the file after the last complete line
+	partial []byte
+	// last is the last line read, whose time and level lines that don't have
+	// any, like stack traces, inherit
+	last Line
+}
+
+func (f *logFile) read() ([]Line, error) {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	// The log was rotated and started again
+	if stat.Size() < f.offset {
+		f.offset = 0
+		f.partial = nil
+	}
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	f.offset += int64(len(b))
+
+	b = append(f.partial, b...)
+	end := bytes.LastIndexByte(b, '\n')
+	if end == -1 {
+		f.partial = b
+		return nil, nil
+	}
+	f.partial = append([]byte{}, b[end+1:]...)
+
+	var lines []Line
+	for _, text := range strings.Split(string(b[:end]), "\n") {
+		if len(text) == 0 {
+			continue
+		}
+		line := Line{
+			Time:  f.last.Time,
+			Node:  f.node,
+			Chain: f.chain,
+			Level: f.last.Level,
+			Text:  text,
+		}
+		if t, level, ok := parseHeader(text); ok {
+			line.Time, line.Level = t, level
+		}
+		f.last = line
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// parseHeader returns the time and level of [text], written by avalanchego in
+// the plain or JSON format
+func parseHeader(text string) (time.Time, logging.Level, bool) {
+	if strings.HasPrefix(text, "{") {
+		var entry struct {
+			Timestamp string `json:"timestamp"`
+			Level     string `json:"level"`
+		}
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return time.Time{}, 0, false
+		}
+		t, err := time.Parse(jsonTimeFormat, entry.Timestamp)
+		if err != nil {
+			return time.Time{}, 0, false
+		}
+		level, err := logging.ToLevel(entry.Level)
+		return t, level, err == nil
+	}
+
+	if len(text) < len(plainTimeFormat) {
+		return time.Time{}, 0, false
+	}
+	t, err := time.ParseInLocation(plainTimeFormat, text[:len(plainTimeFormat)], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	// The plain format leaves out the year
+	t = t.AddDate(time.Now().Year(), 0, 0)
+	fields := strings.Fields(text[len(plainTimeFormat):])
+	if len(fields) == 0 {
+		return time.Time{}, 0, false
+	}
+	level, err := logging.ToLevel(fields[0])
+	return t, level, err == nil
+}
+
+func containsInt(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
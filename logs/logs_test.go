@@ -0,0 +1,89 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/logging"
+)
+
+func TestParseHeader(t *testing.T) {
+	year := time.Now().Year()
+	tests := []struct {
+		name      string
+		text      string
+		wantTime  time.Time
+		wantLevel logging.Level
+		wantOK    bool
+	}{
+		{
+			name:      "plain",
+			text:      "[10-18|12:34:56.789] INFO <C Chain> evm/block.go:42 accepted block",
+			wantTime:  time.Date(year, 10, 18, 12, 34, 56, 789_000_000, time.Local),
+			wantLevel: logging.Info,
+			wantOK:    true,
+		},
+		{
+			name:      "plain warning",
+			text:      "[01-02|03:04:05.006] WARN node/node.go:1 peer disconnected",
+			wantTime:  time.Date(year, 1, 2, 3, 4, 5, 6_000_000, time.Local),
+			wantLevel: logging.Warn,
+			wantOK:    true,
+		},
+		{
+			name:      "json",
+			text:      `{"level":"debug","timestamp":"2025-10-18T12:34:56.789Z","logger":"C","msg":"accepted block"}`,
+			wantTime:  time.Date(2025, 10, 18, 12, 34, 56, 789_000_000, time.UTC),
+			wantLevel: logging.Debug,
+			wantOK:    true,
+		},
+		{
+			name:      "json with offset",
+			text:      `{"level":"error","timestamp":"2025-10-18T12:34:56.789+0200","msg":"failed"}`,
+			wantTime:  time.Date(2025, 10, 18, 10, 34, 56, 789_000_000, time.UTC),
+			wantLevel: logging.Error,
+			wantOK:    true,
+		},
+		{
+			name: "continuation line",
+			text: "\tgoroutine 1 [running]:",
+		},
+		{
+			name: "plain without level",
+			text: "[10-18|12:34:56.789]",
+		},
+		{
+			name: "plain with unknown level",
+			text: "[10-18|12:34:56.789] LOUD something",
+		},
+		{
+			name: "invalid json",
+			text: `{"level":"info"`,
+		},
+		{
+			name: "json without timestamp",
+			text: `{"level":"info","msg":"no time"}`,
+		},
+		{
+			name: "empty",
+			text: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotTime, gotLevel, ok := parseHeader(test.text)
+			if ok != test.wantOK {
+				t.Fatalf("got ok %t, want %t", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !gotTime.Equal(test.wantTime) {
+				t.Errorf("got time %s, want %s", gotTime, test.wantTime)
+			}
+			if gotLevel != test.wantLevel {
+				t.Errorf("got level %s, want %s", gotLevel, test.wantLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/evm"
+	"github.com/ava-labs/ava-sim/load"
+	"github.com/ava-labs/ava-sim/server"
+
+	"github.com/fatih/color"
+)
+
+// histogramWidth is the length of the bar of the fullest bucket of a histogram
+const histogramWidth = 40
+
+// benchmarkCommand issues txs on a chain one at a time and reports how long
+// every node took to accept them
+func benchmarkCommand(args []string) int {
+	fs := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	chain := fs.String("chain", "C", "chain to benchmark: P, X, C, subnet for the chain of the custom VM, or the blockchain ID of an EVM chain")
+	node := fs.Int("node", 1, "node (starting at 1) txs are issued to")
+	txs := fs.Int("txs", 50, "number of txs to issue")
+	interval := fs.Duration("interval", 100*time.Millisecond, "time between a tx being accepted by every node and the next one being issued")
+	timeout := fs.Duration("timeout", 30*time.Second, "time a tx is waited for on every node")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	fs.Parse(args)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	client := server.NewClient(*endpoint)
+	infoCtx, cancelInfo := context.WithTimeout(ctx, constants.HTTPTimeout)
+	info, err := client.Network(infoCtx)
+	if err != nil {
+		cancelInfo()
+		color.Red("could not get the network info: %s", err)
+		return 1
+	}
+	// Nodes that aren't running would only have every tx time out
+	statuses, err := client.Nodes(infoCtx)
+	cancelInfo()
+	if err != nil {
+		color.Red("could not get the status of the nodes: %s", err)
+		return 1
+	}
+	var down []int
+	for i, status := range statuses {
+		if !status.Running || status.Stopped {
+			down = append(down, i)
+		}
+	}
+	for _, i := range down {
+		if i+1 == *node {
+			color.Red("node%d is not running", *node)
+			return 1
+		}
+	}
+	nodeURIs := make([]string, len(info.Nodes))
+	for i, n := range info.Nodes {
+		nodeURIs[i] = n.URI
+	}
+
+	var benchChain load.BenchChain
+	switch *chain {
+	case "P", "X":
+		if *node < 1 || *node > len(info.Nodes) {
+			color.Red("invalid node %d", *node)
+			return 1
+		}
+		benchChain, err = load.NewPrimaryBenchChain(ctx, *chain, nodeURIs[*node-1], nodeURIs)
+	default:
+		var (
+			uri, chainID string
+			nodeURLs     = make([]string, len(nodeURIs))
+		)
+		uri, chainID, err = resolveEVMChain(info, *node, *chain)
+		if err != nil {
+			break
+		}
+		for i, nodeURI := range nodeURIs {
+			nodeURLs[i] = evm.RPCURL(nodeURI, chainID)
+		}
+		benchChain, err = load.NewEVMBenchChain(ctx, evm.RPCURL(uri, chainID), nodeURLs)
+	}
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	defer benchChain.Close()
+
+	color.Cyan("issuing %d txs on %s through node%d", *txs, *chain, *node)
+	report := load.Benchmark(ctx, benchChain, len(nodeURIs), load.BenchConfig{
+		Txs:      *txs,
+		Interval: *interval,
+		Timeout:  *timeout,
+		Down:     down,
+	}, func(i int) {
+		if !*jsonOutput && (i+1)%10 == 0 {
+			fmt.Printf("%d/%d txs\n", i+1, *txs)
+		}
+	})
+
+	if *jsonOutput {
+		reportBytes, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(reportBytes))
+		return 0
+	}
+	if report.Failed > 0 {
+		color.Red("%d txs couldn't be issued", report.Failed)
+	}
+	leftOut := make(map[int]bool, len(report.Down))
+	for _, nodeNum := range report.Down {
+		leftOut[nodeNum] = true
+	}
+	for i, nodeReport := range report.Nodes {
+		if leftOut[i+1] {
+			fmt.Println()
+			color.Yellow("node%d: not running, left out", i+1)
+			continue
+		}
+		printLatencyReport(fmt.Sprintf("node%d", i+1), nodeReport)
+	}
+	printLatencyReport("all nodes", report.All)
+	printLatencyReport("finality (accepted by every node)", report.Finality)
+	return 0
+}
+
+func printLatencyReport(name string, r load.LatencyReport) {
+	fmt.Println()
+	color.Cyan("%s: %d accepted, %d timed out", name, r.Accepted, r.TimedOut)
+	if r.Accepted == 0 {
+		return
+	}
+	fmt.Printf("  mean %.1fms, p50 %.1fms, p90 %.1fms, p99 %.1fms, max %.1fms\n",
+		r.MeanMS, r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+	fullest := 0
+	for _, count := range r.Histogram {
+		if count > fullest {
+			fullest = count
+		}
+	}
+	for i, count := range r.Histogram {
+		label := fmt.Sprintf("> %gms", load.HistogramBounds[len(load.HistogramBounds)-1])
+		if i < len(load.HistogramBounds) {
+			label = fmt.Sprintf("<= %gms", load.HistogramBounds[i])
+		}
+		bar := strings.Repeat("█", int(math.Round(float64(count)/float64(fullest)*histogramWidth)))
+		fmt.Printf("  %-10s %5d %s\n", label, count, bar)
+	}
+}
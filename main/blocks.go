@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/fatih/color"
+)
+
+// Output formats of the EVM commands
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+
+	// blockTableFormat lays out the columns of the table format, whose rows
+	// are printed as blocks come rather than aligned at the end
+	blockTableFormat = "%-10s %-24s %-10s %-6s %-12s %s\n"
+)
+
+// errCountReached stops a stream once enough blocks were printed
+var errCountReached = errors.New("count reached")
+
+// blockRow is a block along with the time since the previous one
+type blockRow struct {
+	evm.Block
+	// IntervalMS is unset for the first block printed
+	IntervalMS *int64 `json:"intervalMs,omitempty"`
+}
+
+// blocksCommand prints the blocks of an EVM chain as they are accepted
+func blocksCommand(args []string) int {
+	fs := flag.NewFlagSet("blocks", flag.ExitOnError)
+	target := addEVMTargetFlags(fs)
+	from := fs.String("from", "latest", "first block to print: a height or latest")
+	count := fs.Uint64("count", 0, "number of blocks to print (default: until interrupted)")
+	format := fs.String("format", formatTable, "output format: table, csv or json")
+	output := fs.String("output", "", "file to write the blocks to (default: stdout)")
+	poll := fs.Bool("poll", false, "poll for new blocks instead of subscribing to them over websocket")
+	fs.Parse(args)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	client, err := target.dial(ctx, !*poll)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	defer client.Close()
+
+	var start uint64
+	if *from == "latest" {
+		block, err := evm.BlockByNumber(ctx, client.Client(), nil)
+		if err != nil {
+			color.Red("could not get the last accepted block: %s", err)
+			return 1
+		}
+		start = block.Number
+	} else if start, err = strconv.ParseUint(*from, 10, 64); err != nil {
+		color.Red("invalid block %q", *from)
+		return 1
+	}
+
+	out := io.Writer(os.Stdout)
+	if len(*output) > 0 {
+		file, err := os.Create(*output)
+		if err != nil {
+			color.Red("could not create output file: %s", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+	writer, err := newBlockWriter(out, *format)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+
+	var (
+		previous *evm.Block
+		printed  uint64
+	)
+	err = evm.StreamBlocks(ctx, client.Client(), start, func(block evm.Block) error {
+		row := blockRow{Block: block}
+		if previous != nil {
+			interval := block.Time.Sub(previous.Time).Milliseconds()
+			row.IntervalMS = &interval
+		}
+		previous = &block
+		if err := writer.write(row); err != nil {
+			return err
+		}
+		printed++
+		if *count > 0 && printed >= *count {
+			return errCountReached
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errCountReached) {
+		color.Red("could not stream blocks: %s", err)
+		return 1
+	}
+	return 0
+}
+
+// blockWriter writes blocks in one of the output formats
+type blockWriter struct {
+	format string
+	table  io.Writer
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+func newBlockWriter(w io.Writer, format string) (*blockWriter, error) {
+	b := &blockWriter{format: format}
+	switch format {
+	case formatTable:
+		b.table = w
+		fmt.Fprintf(w, blockTableFormat, "HEIGHT", "TIME", "INTERVAL", "TXS", "GAS USED", "BASE FEE")
+	case formatCSV:
+		b.csv = csv.NewWriter(w)
+		b.csv.Write([]string{"height", "hash", "timestamp_ms", "interval_ms", "tx_count", "gas_used", "gas_limit", "base_fee", "block_gas_cost"})
+	case formatJSON:
+		b.json = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	return b, nil
+}
+
+func (b *blockWriter) write(row blockRow) error {
+	interval := ""
+	if row.IntervalMS != nil {
+		interval = strconv.FormatInt(*row.IntervalMS, 10)
+	}
+	switch b.format {
+	case formatTable:
+		if len(interval) == 0 {
+			interval = "-"
+		} else {
+			interval += "ms"
+		}
+		_, err := fmt.Fprintf(b.table, blockTableFormat,
+			strconv.FormatUint(row.Number, 10),
+			row.Time.UTC().Format("2006-01-02T15:04:05.000Z"),
+			interval,
+			strconv.Itoa(row.TxCount),
+			strconv.FormatUint(row.GasUsed, 10),
+			bigString(row.BaseFee),
+		)
+		return err
+	case formatCSV:
+		b.csv.Write([]string{
+			strconv.FormatUint(row.Number, 10),
+			row.Hash.Hex(),
+			strconv.FormatInt(row.Time.UnixMilli(), 10),
+			interval,
+			strconv.Itoa(row.TxCount),
+			strconv.FormatUint(row.GasUsed, 10),
+			strconv.FormatUint(row.GasLimit, 10),
+			bigString(row.BaseFee),
+			bigString(row.BlockGasCost),
+		})
+		b.csv.Flush()
+		return b.csv.Error()
+	default:
+		return b.json.Encode(row)
+	}
+}
+
+// bigString formats [n], which is empty if unset
+func bigString(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+	return n.String()
+}
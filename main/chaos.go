@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os/signal"
+	"syscall"
+
+	"github.com/ava-labs/ava-sim/chaos"
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/server"
+
+	"github.com/fatih/color"
+)
+
+// chaosCommand runs a chaos scenario against a running network and returns
+// once it is over. Interrupting the command interrupts the scenario.
+func chaosCommand(args []string) int {
+	fs := flag.NewFlagSet("chaos", flag.ExitOnError)
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		color.Red("expecting a scenario file")
+		return 1
+	}
+	scenario, err := chaos.Load(fs.Arg(0))
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	color.Cyan("running %d steps against %s, events are printed by ava-sim", len(scenario.Steps), *endpoint)
+	statuses, err := server.NewClient(*endpoint).RunScenario(ctx, scenario)
+	if err != nil {
+		color.Red("scenario failed: %s", err)
+		return 1
+	}
+	for _, status := range statuses {
+		color.Green("node%d: running=%t restarts=%d crashes=%d", status.Node, status.Running, status.Restarts, len(status.Crashes))
+	}
+	return 0
+}
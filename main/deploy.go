@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/evm"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/server"
+
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/ava-labs/libevm/ethclient"
+	"github.com/fatih/color"
+)
+
+// deployContractCommand deploys a contract on an EVM chain of the running
+// network and records its address in the network info. The constructor
+// arguments follow the flags.
+func deployContractCommand(args []string) int {
+	fs := flag.NewFlagSet("deploy-contract", flag.ExitOnError)
+	target := addEVMTargetFlags(fs)
+	artifactPath := fs.String("artifact", "", "JSON artifact of the compiled contract (Hardhat, Foundry or solc)")
+	bytecode := fs.String("bytecode", "", "hex bytecode of the contract, or a file holding it")
+	constructor := fs.String("constructor", "", "constructor signature the arguments are encoded with, e.g. \"constructor(string,uint256)\" (default: from the artifact ABI)")
+	name := fs.String("name", "", "name the contract is recorded under (default: from the artifact)")
+	keyHex := fs.String("key", "", "hex private key deploying the contract (default: the key funded in the local genesis)")
+	value := fs.String("value", "0", "AVAX sent to the constructor")
+	timeout := fs.Duration("timeout", 2*time.Minute, "how long to wait for the contract to be deployed")
+	jsonOutput := fs.Bool("json", false, "print the deployed contract as JSON")
+	fs.Parse(args)
+
+	if (len(*artifactPath) == 0) == (len(*bytecode) == 0) {
+		color.Red("exactly one of --artifact and --bytecode must be set")
+		return 1
+	}
+	var artifact evm.Artifact
+	if len(*artifactPath) > 0 {
+		artifactBytes, err := ioutil.ReadFile(*artifactPath)
+		if err != nil {
+			color.Red("could not read artifact: %s", err)
+			return 1
+		}
+		if artifact, err = evm.ParseArtifact(artifactBytes); err != nil {
+			color.Red("%s", err)
+			return 1
+		}
+		if len(artifact.Name) == 0 {
+			artifact.Name = strings.TrimSuffix(filepath.Base(*artifactPath), filepath.Ext(*artifactPath))
+		}
+	} else {
+		code := *bytecode
+		if codeBytes, err := ioutil.ReadFile(code); err == nil {
+			code = string(codeBytes)
+		}
+		var err error
+		if artifact.Bytecode, err = evm.ParseBytecode(code); err != nil {
+			color.Red("%s", err)
+			return 1
+		}
+	}
+	if len(*constructor) > 0 {
+		var err error
+		if artifact.Constructor, err = evm.ParseConstructor(*constructor); err != nil {
+			color.Red("%s", err)
+			return 1
+		}
+	}
+	packedArgs, err := evm.PackConstructorArgs(artifact.Constructor, fs.Args())
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	code := append(artifact.Bytecode, packedArgs...)
+	if len(*name) > 0 {
+		artifact.Name = *name
+	}
+	if len(artifact.Name) == 0 {
+		artifact.Name = "contract"
+	}
+
+	key := evm.FundedKey()
+	if len(*keyHex) > 0 {
+		if key, err = crypto.HexToECDSA(strings.TrimPrefix(*keyHex, "0x")); err != nil {
+			color.Red("invalid key: %s", err)
+			return 1
+		}
+	}
+	amount, ok := new(big.Rat).SetString(*value)
+	if !ok || amount.Sign() < 0 {
+		color.Red("invalid value %q", *value)
+		return 1
+	}
+	amount.Mul(amount, weiPerAVAX)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, *timeout)
+	defer cancelTimeout()
+	uri, chain, err := target.resolve(ctx)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	url := evm.RPCURL(uri, chain)
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		color.Red("could not connect to %s: %s", url, err)
+		return 1
+	}
+	defer client.Close()
+
+	if !*jsonOutput {
+		color.Cyan("deploying %s (%d bytes) on %s from %s", artifact.Name, len(code), chain, evm.Address(key))
+	}
+	receipt, err := evm.Deploy(ctx, client, key, code, new(big.Int).Quo(amount.Num(), amount.Denom()))
+	if err != nil {
+		color.Red("could not deploy %s: %s", artifact.Name, err)
+		return 1
+	}
+	contract := manager.ContractInfo{
+		Name:     artifact.Name,
+		Chain:    chain,
+		Address:  receipt.ContractAddress.Hex(),
+		TxHash:   receipt.TxHash.Hex(),
+		Deployer: evm.Address(key).Hex(),
+		Block:    receipt.BlockNumber.Uint64(),
+	}
+
+	// The contract is deployed even if it can't be recorded
+	recordCtx, cancelRecord := context.WithTimeout(context.Background(), constants.HTTPTimeout)
+	defer cancelRecord()
+	if _, err := server.NewClient(*target.endpoint).AddContract(recordCtx, contract); err != nil {
+		color.Yellow("could not record %s in the network info: %s", contract.Name, err)
+	}
+
+	if *jsonOutput {
+		contractBytes, _ := json.MarshalIndent(contract, "", "  ")
+		fmt.Println(string(contractBytes))
+		return 0
+	}
+	color.Green("%s deployed at %s", contract.Name, contract.Address)
+	fmt.Printf("tx:    %s\nblock: %d\ngas:   %d\n", contract.TxHash, contract.Block, receipt.GasUsed)
+	return 0
+}
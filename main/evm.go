@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/evm"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/server"
+
+	"github.com/ava-labs/libevm/ethclient"
+)
+
+// subnetChain selects the chain of the custom VM
+const subnetChain = "subnet"
+
+var errNoSubnet = errors.New("the network has no custom chain")
+
+// evmTarget is the EVM chain, and the node serving it, the EVM commands talk to
+type evmTarget struct {
+	chain    *string
+	node     *int
+	endpoint *string
+}
+
+func addEVMTargetFlags(fs *flag.FlagSet) *evmTarget {
+	return &evmTarget{
+		chain:    fs.String("chain", "C", "EVM chain to use: C, subnet for the chain of the custom VM, or a blockchain ID"),
+		node:     fs.Int("node", 1, "node (starting at 1) requests are sent to"),
+		endpoint: fs.String("endpoint", constants.APIURL, "ava-sim API of the running network"),
+	}
+}
+
+// resolve returns the URI of the node and the ID or alias of the chain, as
+// found in the info of the running network
+func (t *evmTarget) resolve(ctx context.Context) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, constants.HTTPTimeout)
+	defer cancel()
+	info, err := server.NewClient(*t.endpoint).Network(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("could not get the network info: %w", err)
+	}
+	return resolveEVMChain(info, *t.node, *t.chain)
+}
+
+// dial connects to the chain over websocket if [ws] is set, or over HTTP
+func (t *evmTarget) dial(ctx context.Context, ws bool) (*ethclient.Client, error) {
+	uri, chain, err := t.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	url := evm.RPCURL(uri, chain)
+	if ws {
+		url = evm.WSURL(uri, chain)
+	}
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", url, err)
+	}
+	return client, nil
+}
+
+func resolveEVMChain(info manager.NetworkInfo, nodeNum int, chain string) (string, string, error) {
+	if nodeNum < 1 || nodeNum > len(info.Nodes) {
+		return "", "", fmt.Errorf("invalid node %d", nodeNum)
+	}
+	if chain == subnetChain {
+		if info.Subnet == nil {
+			return "", "", errNoSubnet
+		}
+		chain = info.Subnet.BlockchainID
+	}
+	return info.Nodes[nodeNum-1].URI, chain, nil
+}
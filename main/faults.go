@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/proxy"
+	"github.com/ava-labs/ava-sim/server"
+
+	"github.com/fatih/color"
+)
+
+// partitionCommand splits a network started with --link-proxy into the groups
+// of nodes given as arguments, e.g. "1,2,3 4,5"
+func partitionCommand(args []string) int {
+	fs := flag.NewFlagSet("partition", flag.ExitOnError)
+	duration := fs.Duration("duration", 0, "heal the partition after this long (default: until ava-sim heal)")
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		color.Red("expecting the groups of nodes to split the network into (e.g. 1,2,3 4,5)")
+		return 1
+	}
+	groups := make([][]int, fs.NArg())
+	for i, group := range fs.Args() {
+		nodes, err := parseNodeList(group, constants.NumNodes)
+		if err != nil {
+			color.Red("%s", err)
+			return 1
+		}
+		groups[i] = nodes
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.HTTPTimeout)
+	defer cancel()
+	reply, err := server.NewClient(*endpoint).Partition(ctx, groups, *duration)
+	if err != nil {
+		color.Red("could not partition the network: %s", err)
+		return 1
+	}
+	if reply.Partition != nil && reply.Partition.HealAt != nil {
+		color.Green("network partitioned into %v until %s", groups, reply.Partition.HealAt.Format(time.RFC3339))
+	} else {
+		color.Green("network partitioned into %v", groups)
+	}
+	return 0
+}
+
+// healCommand removes the partition of a running network
+func healCommand(args []string) int {
+	fs := flag.NewFlagSet("heal", flag.ExitOnError)
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.HTTPTimeout)
+	defer cancel()
+	if _, err := server.NewClient(*endpoint).Heal(ctx); err != nil {
+		color.Red("could not heal the network: %s", err)
+		return 1
+	}
+	color.Green("network healed")
+	return 0
+}
+
+// linkCommand shapes and injects faults on the links between the nodes of a network started with
+// --link-proxy
+func linkCommand(args []string) int {
+	fs := flag.NewFlagSet("link", flag.ExitOnError)
+	from := fs.String("from", "", "comma separated nodes the links start from (default: all nodes)")
+	to := fs.String("to", "", "comma separated nodes the links go to (default: all nodes)")
+	oneWay := fs.Bool("one-way", false, "only shape the links from --from to --to, not the ones going back")
+	latency := fs.Duration("latency", 0, "delay of every message")
+	jitter := fs.Duration("jitter", 0, "random variation of the delay of every message")
+	bandwidth := fs.Int("bandwidth", 0, "maximum bytes per second sent over each link (default: unlimited)")
+	dropRate := fs.Float64("drop-rate", 0, "probability for every message to be dropped (0 to 1)")
+	blackhole := fs.Bool("blackhole", false, "drop every message while keeping connections open")
+	resetInterval := fs.Duration("reset-interval", 0, "close connections once they have been open this long")
+	reset := fs.Bool("reset", false, "remove the config of every link")
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.HTTPTimeout)
+	defer cancel()
+	client := server.NewClient(*endpoint)
+	if *reset {
+		if _, err := client.ResetLinks(ctx); err != nil {
+			color.Red("could not reset links: %s", err)
+			return 1
+		}
+		color.Green("links reset")
+		return 0
+	}
+
+	linkArgs := server.LinkArgs{
+		OneWay: *oneWay,
+		Config: proxy.LinkConfig{
+			Latency:       proxy.Duration(*latency),
+			Jitter:        proxy.Duration(*jitter),
+			Bandwidth:     *bandwidth,
+			DropRate:      *dropRate,
+			Blackhole:     *blackhole,
+			ResetInterval: proxy.Duration(*resetInterval),
+		},
+	}
+	var err error
+	if len(*from) > 0 {
+		if linkArgs.From, err = parseNodeList(*from, constants.NumNodes); err != nil {
+			color.Red("%s", err)
+			return 1
+		}
+	}
+	if len(*to) > 0 {
+		if linkArgs.To, err = parseNodeList(*to, constants.NumNodes); err != nil {
+			color.Red("%s", err)
+			return 1
+		}
+	}
+	reply, err := client.SetLinks(ctx, linkArgs)
+	if err != nil {
+		color.Red("could not set links: %s", err)
+		return 1
+	}
+	for _, l := range reply.Links {
+		color.Green("node%d -> node%d: %+v", l.From, l.To, l.Config)
+	}
+	return 0
+}
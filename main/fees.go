@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/fatih/color"
+)
+
+// feesTableFormat lays out the per block table of the fees command
+const feesTableFormat = "%-10s %-6s %-14s %-12s %-10s %-14s %s\n"
+
+// feesBlock is a block reported by the fees command, with fees in gwei and
+// gas in gas units
+type feesBlock struct {
+	Number         uint64            `json:"number"`
+	TxCount        int               `json:"txCount"`
+	BaseFee        string            `json:"baseFeeGwei"`
+	GasUsed        uint64            `json:"gasUsed"`
+	ExtDataGasUsed uint64            `json:"extDataGasUsed"`
+	BlockGasCost   string            `json:"blockGasCost"` // in gas units, as in the header
+	Tips           map[string]string `json:"tipPercentilesGwei"`
+	GasPrices      map[string]string `json:"gasPricePercentilesGwei"`
+}
+
+// feesSummary aggregates the fees of every block, in gwei
+type feesSummary struct {
+	Blocks       int               `json:"blocks"`
+	Txs          int               `json:"txs"`
+	MinBaseFee   string            `json:"minBaseFeeGwei"`
+	MaxBaseFee   string            `json:"maxBaseFeeGwei"`
+	AvgBaseFee   string            `json:"avgBaseFeeGwei"`
+	Tips         map[string]string `json:"tipPercentilesGwei"`
+	GasPrices    map[string]string `json:"gasPricePercentilesGwei"`
+	SuggestedTip string            `json:"suggestedTipGwei"`
+}
+
+// feesCommand reports the base fees, tips and effective gas prices paid over a
+// range of blocks of an EVM chain
+func feesCommand(args []string) int {
+	fs := flag.NewFlagSet("fees", flag.ExitOnError)
+	target := addEVMTargetFlags(fs)
+	to := fs.String("to", "latest", "last block of the range: a height or latest")
+	count := fs.Uint64("count", 10, "number of blocks of the range, ending at --to")
+	percentilesFlag := fs.String("percentiles", "10,25,50,75,90", "comma separated percentiles of the tips and gas prices to report")
+	format := fs.String("format", formatTable, "output format: table or json")
+	fs.Parse(args)
+
+	if *format != formatTable && *format != formatJSON {
+		color.Red("unknown format %q", *format)
+		return 1
+	}
+	var percentiles []float64
+	for _, p := range strings.Split(*percentilesFlag, ",") {
+		percentile, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || percentile < 0 || percentile > 100 {
+			color.Red("invalid percentile %q", p)
+			return 1
+		}
+		percentiles = append(percentiles, percentile)
+	}
+
+	ctx := context.Background()
+	client, err := target.dial(ctx, false)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	defer client.Close()
+
+	var last uint64
+	if *to == "latest" {
+		block, err := evm.BlockByNumber(ctx, client.Client(), nil)
+		if err != nil {
+			color.Red("could not get the last accepted block: %s", err)
+			return 1
+		}
+		last = block.Number
+	} else if last, err = strconv.ParseUint(*to, 10, 64); err != nil {
+		color.Red("invalid block %q", *to)
+		return 1
+	}
+	first := uint64(0)
+	if *count > 0 && last+1 > *count {
+		first = last + 1 - *count
+	}
+
+	var (
+		blocks             []feesBlock
+		allTips, allPrices []*big.Int
+		baseFees           []*big.Int
+		summary            feesSummary
+	)
+	for number := first; number <= last; number++ {
+		fees, err := evm.BlockFeesByNumber(ctx, client.Client(), new(big.Int).SetUint64(number))
+		if err != nil {
+			color.Red("could not get block %d: %s", number, err)
+			return 1
+		}
+		blocks = append(blocks, feesBlock{
+			Number:         fees.Number,
+			TxCount:        fees.TxCount,
+			BaseFee:        evm.Gwei(fees.BaseFee),
+			GasUsed:        fees.GasUsed,
+			ExtDataGasUsed: fees.ExtDataGasUsed,
+			BlockGasCost:   bigString(fees.BlockGasCost),
+			Tips:           percentilesGwei(fees.Tips, percentiles),
+			GasPrices:      percentilesGwei(fees.GasPrices, percentiles),
+		})
+		summary.Txs += fees.TxCount
+		allTips = append(allTips, fees.Tips...)
+		allPrices = append(allPrices, fees.GasPrices...)
+		if fees.BaseFee != nil {
+			baseFees = append(baseFees, fees.BaseFee)
+		}
+	}
+
+	summary.Blocks = len(blocks)
+	evm.SortBig(allTips)
+	evm.SortBig(allPrices)
+	summary.Tips = percentilesGwei(allTips, percentiles)
+	summary.GasPrices = percentilesGwei(allPrices, percentiles)
+	if len(baseFees) > 0 {
+		evm.SortBig(baseFees)
+		sum := new(big.Int)
+		for _, baseFee := range baseFees {
+			sum.Add(sum, baseFee)
+		}
+		summary.MinBaseFee = evm.Gwei(baseFees[0])
+		summary.MaxBaseFee = evm.Gwei(baseFees[len(baseFees)-1])
+		summary.AvgBaseFee = evm.Gwei(sum.Div(sum, big.NewInt(int64(len(baseFees)))))
+	}
+	if tip, err := client.SuggestGasTipCap(ctx); err == nil {
+		summary.SuggestedTip = evm.Gwei(tip)
+	}
+
+	if *format == formatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, block := range blocks {
+			encoder.Encode(block)
+		}
+		encoder.Encode(map[string]feesSummary{"summary": summary})
+		return 0
+	}
+
+	median := formatPercentile(50)
+	fmt.Printf(feesTableFormat, "HEIGHT", "TXS", "BASE FEE", "GAS USED", "COST (GAS)", "TIP P50", "PRICE P50")
+	for _, block := range blocks {
+		fmt.Printf(feesTableFormat,
+			strconv.FormatUint(block.Number, 10),
+			strconv.Itoa(block.TxCount),
+			block.BaseFee,
+			strconv.FormatUint(block.GasUsed+block.ExtDataGasUsed, 10),
+			block.BlockGasCost,
+			orDash(block.Tips[median]),
+			orDash(block.GasPrices[median]),
+		)
+	}
+	fmt.Println()
+	fmt.Printf("%d blocks, %d txs (fees in gwei)\n", summary.Blocks, summary.Txs)
+	fmt.Printf("base fee: min %s, avg %s, max %s\n", orDash(summary.MinBaseFee), orDash(summary.AvgBaseFee), orDash(summary.MaxBaseFee))
+	for _, p := range percentiles {
+		key := formatPercentile(p)
+		fmt.Printf("%-5s tip %-14s gas price %s\n", key, orDash(summary.Tips[key]), orDash(summary.GasPrices[key]))
+	}
+	fmt.Printf("suggested tip: %s\n", orDash(summary.SuggestedTip))
+	return 0
+}
+
+// percentilesGwei returns the [percentiles] of [sorted] in gwei, keyed by
+// percentile
+func percentilesGwei(sorted []*big.Int, percentiles []float64) map[string]string {
+	values := make(map[string]string, len(percentiles))
+	for _, p := range percentiles {
+		values[formatPercentile(p)] = evm.Gwei(evm.Percentile(sorted, p))
+	}
+	return values
+}
+
+func formatPercentile(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/health"
+	"github.com/ava-labs/ava-sim/server"
+
+	"github.com/fatih/color"
+)
+
+// healthCommand prints the health of every node of a running network and
+// fails if any of them is unhealthy
+func healthCommand(args []string) int {
+	fs := flag.NewFlagSet("health", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "print the health of every node as JSON")
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.HTTPTimeout)
+	defer cancel()
+	info, err := server.NewClient(*endpoint).Network(ctx)
+	if err != nil {
+		color.Red("could not get the network info: %s", err)
+		return 1
+	}
+	report := health.NewChecker(info.Nodes, nil).Check(ctx)
+
+	healthy := true
+	for _, h := range report {
+		healthy = healthy && h.Healthy
+	}
+	if *jsonOutput {
+		reportBytes, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(reportBytes))
+	} else {
+		for _, h := range report {
+			if h.Healthy {
+				color.Green("node%d (%s): healthy", h.Node, h.NodeID)
+				continue
+			}
+			if len(h.Error) > 0 {
+				color.Red("node%d (%s): unreachable: %s", h.Node, h.NodeID, h.Error)
+				continue
+			}
+			color.Red("node%d (%s): unhealthy", h.Node, h.NodeID)
+			for _, check := range h.FailingChecks() {
+				color.Red("  %s: %s", check, h.Failing[check])
+			}
+		}
+	}
+	if !healthy {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/evm"
+	"github.com/ava-labs/ava-sim/load"
+	"github.com/ava-labs/ava-sim/server"
+
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/fatih/color"
+)
+
+// weiPerAVAX converts amounts of AVAX to the wei EVM chains count them in
+var weiPerAVAX = big.NewRat(1_000_000_000_000_000_000, 1)
+
+// loadCommand issues transfers on an EVM chain at a constant or ramping rate
+// and reports how many were accepted and how long it took
+func loadCommand(args []string) int {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	target := addEVMTargetFlags(fs)
+	rate := fs.Float64("rate", 10, "txs issued per second")
+	rampFrom := fs.Float64("ramp-from", 0, "rate the ramp starts from")
+	ramp := fs.Duration("ramp", 0, "time the rate ramps up from --ramp-from to --rate over (0 issues at --rate from the start)")
+	duration := fs.Duration("duration", time.Minute, "time txs are issued for (0 issues until interrupted)")
+	accounts := fs.Int("accounts", 100, "number of accounts txs are issued from")
+	fund := fs.String("fund", "10", "AVAX the accounts are funded with")
+	funderKey := fs.String("funder-key", "", "hex private key funding the accounts (default: the key funded in the local genesis)")
+	reportInterval := fs.Duration("report-interval", 5*time.Second, "time between progress reports")
+	drain := fs.Duration("drain", 30*time.Second, "time pending txs are waited for once issuing stopped")
+	jsonOutput := fs.Bool("json", false, "print the final report as JSON")
+	fs.Parse(args)
+
+	profile := load.Profile{
+		Rate:      *rate,
+		StartRate: *rampFrom,
+		Ramp:      *ramp,
+		Duration:  *duration,
+	}
+	if err := profile.Verify(); err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	if *accounts < 1 {
+		color.Red("at least one account is needed")
+		return 1
+	}
+	funding, ok := new(big.Rat).SetString(*fund)
+	if !ok || funding.Sign() <= 0 {
+		color.Red("invalid funding %q", *fund)
+		return 1
+	}
+	funding.Mul(funding, weiPerAVAX)
+	funder := evm.FundedKey()
+	if len(*funderKey) > 0 {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(*funderKey, "0x"))
+		if err != nil {
+			color.Red("invalid funder key: %s", err)
+			return 1
+		}
+		funder = key
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	uri, chain, err := target.resolve(ctx)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	stats := load.NewStats()
+	generator, err := load.NewEVM(ctx, load.EVMConfig{
+		RPCURL:   evm.RPCURL(uri, chain),
+		WSURL:    evm.WSURL(uri, chain),
+		Accounts: *accounts,
+		Funder:   funder,
+		Funding:  new(big.Int).Quo(funding.Num(), funding.Denom()),
+	}, stats)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	defer generator.Close()
+
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- generator.Watch(watchCtx)
+	}()
+	go func() {
+		ticker := time.NewTicker(*reportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Println(stats.Report())
+			case <-watchCtx.Done():
+				return
+			}
+		}
+	}()
+
+	color.Cyan("issuing %s from %d accounts on %s", describeProfile(profile), *accounts, chain)
+	load.Run(ctx, profile, generator.Workers(), stats, generator.Issue)
+
+	// Txs still pending are waited for unless interrupted again
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), *drain)
+	defer cancelDrain()
+	drainCtx, stopDrain := signal.NotifyContext(drainCtx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopDrain()
+	for stats.Pending() > 0 && drainCtx.Err() == nil {
+		select {
+		case err := <-watchErr:
+			color.Red("could not watch blocks: %s", err)
+			stopDrain()
+		case <-drainCtx.Done():
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	stopWatching()
+
+	report := stats.Report()
+	if *jsonOutput {
+		reportBytes, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(reportBytes))
+		return 0
+	}
+	color.Green("%s", report)
+	for err, count := range report.Errors {
+		color.Red("%d× %s", count, err)
+	}
+	return 0
+}
+
+func describeProfile(profile load.Profile) string {
+	description := fmt.Sprintf("%g tx/s", profile.Rate)
+	if profile.Ramp > 0 {
+		description = fmt.Sprintf("%g to %g tx/s over %s", profile.StartRate, profile.Rate, profile.Ramp)
+	}
+	if profile.Duration > 0 {
+		description += fmt.Sprintf(" for %s", profile.Duration)
+	}
+	return description
+}
+
+// loadPrimaryCommand issues transfers on the X-chain and between the P, X and
+// C chains at the rate set for each kind of transfer, and reports how many were
+// accepted and how long it took per kind
+func loadPrimaryCommand(args []string) int {
+	fs := flag.NewFlagSet("load-primary", flag.ExitOnError)
+	rates := fs.String("rates", "x-transfer=5,x-to-p=1,p-to-x=1,x-to-c=1,c-to-x=1", "transfers issued per second, per kind: "+strings.Join(load.Kinds, ", "))
+	ramp := fs.Duration("ramp", 0, "time the rates ramp up from 0 over (0 issues at the full rates from the start)")
+	duration := fs.Duration("duration", time.Minute, "time transfers are issued for (0 issues until interrupted)")
+	keys := fs.Int("keys", 50, "number of keys transfers are issued from")
+	amount := fs.String("amount", "0.01", "AVAX sent by every transfer")
+	fund := fs.String("fund", "10", "AVAX the keys are funded with on each chain")
+	node := fs.Int("node", 1, "node (starting at 1) txs are issued to")
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	reportInterval := fs.Duration("report-interval", 5*time.Second, "time between progress reports")
+	jsonOutput := fs.Bool("json", false, "print the final reports as JSON")
+	fs.Parse(args)
+
+	profiles := make(map[string]load.Profile)
+	for _, kindRate := range strings.Split(*rates, ",") {
+		parts := strings.SplitN(strings.TrimSpace(kindRate), "=", 2)
+		if len(parts) != 2 {
+			color.Red("invalid rate %q: expected <kind>=<rate>", kindRate)
+			return 1
+		}
+		if !knownKind(parts[0]) {
+			color.Red("unknown kind of transfer %q", parts[0])
+			return 1
+		}
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			color.Red("invalid rate %q", kindRate)
+			return 1
+		}
+		if rate == 0 {
+			continue
+		}
+		profile := load.Profile{
+			Rate:     rate,
+			Ramp:     *ramp,
+			Duration: *duration,
+		}
+		if err := profile.Verify(); err != nil {
+			color.Red("%s: %s", parts[0], err)
+			return 1
+		}
+		profiles[parts[0]] = profile
+	}
+	if len(profiles) == 0 {
+		color.Red("no transfer to issue")
+		return 1
+	}
+	if *keys < 1 {
+		color.Red("at least one key is needed")
+		return 1
+	}
+	amountNAVAX, err := parseNAVAX(*amount)
+	if err != nil {
+		color.Red("invalid amount: %s", err)
+		return 1
+	}
+	fundNAVAX, err := parseNAVAX(*fund)
+	if err != nil {
+		color.Red("invalid funding: %s", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	infoCtx, cancelInfo := context.WithTimeout(ctx, constants.HTTPTimeout)
+	info, err := server.NewClient(*endpoint).Network(infoCtx)
+	cancelInfo()
+	if err != nil {
+		color.Red("could not get the network info: %s", err)
+		return 1
+	}
+	if *node < 1 || *node > len(info.Nodes) {
+		color.Red("invalid node %d", *node)
+		return 1
+	}
+	generator, err := load.NewPrimary(ctx, load.PrimaryConfig{
+		URI:     info.Nodes[*node-1].URI,
+		Keys:    *keys,
+		Amount:  amountNAVAX,
+		Funding: fundNAVAX,
+	})
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+
+	kinds := make([]string, 0, len(profiles))
+	issuers := make(map[string]func(context.Context) error, len(profiles))
+	stats := make(map[string]*load.Stats, len(profiles))
+	for _, kind := range load.Kinds {
+		if _, ok := profiles[kind]; !ok {
+			continue
+		}
+		kinds = append(kinds, kind)
+		stats[kind] = load.NewStats()
+		issuers[kind], _ = generator.Issuer(kind, stats[kind])
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(*reportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				for _, kind := range kinds {
+					fmt.Printf("%-10s %s\n", kind, stats[kind].Report())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, kind := range kinds {
+		kind := kind
+		color.Cyan("issuing %s %s", kind, describeProfile(profiles[kind]))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			load.Run(ctx, profiles[kind], generator.Workers(), stats[kind], issuers[kind])
+		}()
+	}
+	wg.Wait()
+	close(done)
+
+	reports := make(map[string]load.Report, len(kinds))
+	for _, kind := range kinds {
+		reports[kind] = stats[kind].Report()
+	}
+	if *jsonOutput {
+		reportsBytes, _ := json.MarshalIndent(reports, "", "  ")
+		fmt.Println(string(reportsBytes))
+		return 0
+	}
+	for _, kind := range kinds {
+		color.Green("%-10s %s", kind, reports[kind])
+		for err, count := range reports[kind].Errors {
+			color.Red("%-10s %d× %s", "", count, err)
+		}
+	}
+	return 0
+}
+
+func knownKind(kind string) bool {
+	for _, k := range load.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNAVAX parses an amount of AVAX into nAVAX
+func parseNAVAX(avax string) (uint64, error) {
+	amount, ok := new(big.Rat).SetString(avax)
+	if !ok || amount.Sign() <= 0 {
+		return 0, fmt.Errorf("%q is not a positive amount of AVAX", avax)
+	}
+	amount.Mul(amount, big.NewRat(1_000_000_000, 1))
+	nAVAX := new(big.Int).Quo(amount.Num(), amount.Denom())
+	if !nAVAX.IsUint64() {
+		return 0, fmt.Errorf("%q is too large", avax)
+	}
+	return nAVAX.Uint64(), nil
+}
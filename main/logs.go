@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/logs"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/server"
+	"github.com/ava-labs/avalanchego/utils/logging"
+
+	"github.com/fatih/color"
+)
+
+// chainPrefixLength is how much of a blockchain ID is kept in the prefix of
+// its log lines
+const chainPrefixLength = 8
+
+// nodeColors tell the lines of every node apart
+var nodeColors = []color.Attribute{
+	color.FgCyan,
+	color.FgGreen,
+	color.FgYellow,
+	color.FgBlue,
+	color.FgMagenta,
+}
+
+// logsCommand prints the logs of every node merged by time, each line prefixed
+// with its node and chain
+func logsCommand(args []string) int {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	nodes := fs.String("node", "", "comma separated nodes to print the logs of (default: all nodes)")
+	chains := fs.String("chain", "", "comma separated chains to print the logs of, e.g. main,P,C,X or a blockchain ID (default: all chains)")
+	level := fs.String("level", "verbo", "lowest level of the lines to print")
+	pattern := fs.String("grep", "", "regular expression the lines to print must match")
+	follow := fs.Bool("follow", false, "keep printing new lines as they are logged")
+	fs.BoolVar(follow, "f", false, "shorthand for --follow")
+	dir := fs.String("dir", "", "tmp dir of the network to read the logs of (default: the one of the running network)")
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	fs.Parse(args)
+
+	filter := logs.Filter{}
+	var err error
+	if len(*nodes) > 0 {
+		if filter.Nodes, err = parseNodeList(*nodes, constants.NumNodes); err != nil {
+			color.Red("%s", err)
+			return 1
+		}
+	}
+	if len(*chains) > 0 {
+		filter.Chains = strings.Split(*chains, ",")
+	}
+	if filter.Level, err = logging.ToLevel(*level); err != nil {
+		color.Red("invalid level: %s", err)
+		return 1
+	}
+	if len(*pattern) > 0 {
+		if filter.Pattern, err = regexp.Compile(*pattern); err != nil {
+			color.Red("invalid pattern: %s", err)
+			return 1
+		}
+	}
+
+	var info manager.NetworkInfo
+	if len(*dir) > 0 {
+		info = manager.NewNetworkInfo(*dir)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.HTTPTimeout)
+		info, err = server.NewClient(*endpoint).Network(ctx)
+		cancel()
+		if err != nil {
+			color.Red("could not get the network info: %s", err)
+			return 1
+		}
+	}
+	logDirs := make([]string, len(info.Nodes))
+	for i, node := range info.Nodes {
+		logDirs[i] = node.LogDir
+	}
+
+	tailer := logs.New(logDirs, filter)
+	if !*follow {
+		lines, err := tailer.Read()
+		if err != nil {
+			color.Red("could not read logs: %s", err)
+			return 1
+		}
+		for _, line := range lines {
+			printLine(line)
+		}
+		return 0
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	if err := tailer.Follow(ctx, printLine); err != nil {
+		color.Red("could not read logs: %s", err)
+		return 1
+	}
+	return 0
+}
+
+func printLine(line logs.Line) {
+	chain := line.Chain
+	if len(chain) > chainPrefixLength {
+		chain = chain[:chainPrefixLength]
+	}
+	prefix := color.New(nodeColors[(line.Node-1)%len(nodeColors)]).Sprintf("node%d %-*s |", line.Node, chainPrefixLength, chain)
+	fmt.Fprintln(os.Stdout, prefix, line.Text)
+}
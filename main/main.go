@@ -2,33 +2,207 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"path"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	"github.com/ava-labs/ava-sim/chaos"
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/events"
+	"github.com/ava-labs/ava-sim/health"
 	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/metrics"
+	"github.com/ava-labs/ava-sim/monitor"
 	"github.com/ava-labs/ava-sim/runner"
+	"github.com/ava-labs/ava-sim/server"
+	"github.com/ava-labs/ava-sim/utils"
 	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/upgrade"
 	"github.com/fatih/color"
 	"golang.org/x/sync/errgroup"
 )
 
+// Exit codes reported by ava-sim so callers can tell failures apart. Code 2 is
+// skipped because the Go runtime uses it for unrecovered panics.
+const (
+	exitCodeSuccess         = 0
+	exitCodeFailure         = 1
+	exitCodeBootstrapFailed = 3
+	exitCodeSubnetFailed    = 4
+	exitCodeNodeCrashed     = 5
+	exitCodeChaosFailed     = 6
+	exitCodeSafetyViolation = 7
+	exitCodeLivenessStall   = 8
+)
+
+var (
+	errSubnetSetup = errors.New("subnet setup failed")
+	errChaos       = errors.New("chaos scenario failed")
+
+	// defaultReadyFile is where the network info is written once the network
+	// is ready to be used
+	defaultReadyFile = filepath.Join(os.TempDir(), "ava-sim", "ready.json")
+)
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "wait":
+			os.Exit(waitCommand(os.Args[2:]))
+		case "upgrade":
+			os.Exit(upgradeCommand(os.Args[2:]))
+		case "partition":
+			os.Exit(partitionCommand(os.Args[2:]))
+		case "heal":
+			os.Exit(healCommand(os.Args[2:]))
+		case "link":
+			os.Exit(linkCommand(os.Args[2:]))
+		case "chaos":
+			os.Exit(chaosCommand(os.Args[2:]))
+		case "logs":
+			os.Exit(logsCommand(os.Args[2:]))
+		case "tui":
+			os.Exit(tuiCommand(os.Args[2:]))
+		case "health":
+			os.Exit(healthCommand(os.Args[2:]))
+		case "blocks":
+			os.Exit(blocksCommand(os.Args[2:]))
+		case "fees":
+			os.Exit(feesCommand(os.Args[2:]))
+		case "timestamp":
+			os.Exit(timestampCommand(os.Args[2:]))
+		case "state":
+			os.Exit(stateCommand(os.Args[2:]))
+		case "load":
+			os.Exit(loadCommand(os.Args[2:]))
+		case "load-primary":
+			os.Exit(loadPrimaryCommand(os.Args[2:]))
+		case "benchmark":
+			os.Exit(benchmarkCommand(os.Args[2:]))
+		case "record":
+			os.Exit(recordCommand(os.Args[2:]))
+		case "replay":
+			os.Exit(replayCommand(os.Args[2:]))
+		case "deploy-contract":
+			os.Exit(deployContractCommand(os.Args[2:]))
+		}
+	}
+
+	readyFile := flag.String("ready-file", defaultReadyFile, "file the network info is written to once the network is ready")
+	restart := flag.String("restart", string(manager.RestartNever), "restart policy of every node: never, on-failure[:max-restarts] or always[:max-restarts]")
+	nodeRestart := flag.String("node-restart", "", "per node restart policies overriding --restart (e.g. 3=always,5=on-failure:2)")
+	avalanchegoPath := flag.String("avalanchego-path", "", "run every node as a separate process of this avalanchego binary instead of in-process")
+	nodeAvalanchegoPath := flag.String("node-avalanchego-path", "", "per node avalanchego binaries overriding --avalanchego-path (e.g. 1=/path/v1.13.4,2=/path/v1.13.5)")
+	upgradeFile := flag.String("upgrade-file", "", "avalanchego upgrade file replacing the default local network upgrade schedule")
+	upgradeSchedule := flag.String("upgrade", "", "upgrade activation times, relative to start or RFC3339 (e.g. granite=+10m,fortuna=+5m)")
+	linkProxy := flag.Bool("link-proxy", false, "proxy the connections between nodes so that faults can be injected on them")
+	benchlistFailThreshold := flag.Int("benchlist-fail-threshold", 0, "consecutive failed queries before a node benches a peer (default: 10)")
+	benchlistDuration := flag.Duration("benchlist-duration", 0, "longest a peer stays benched (default: 1h)")
+	benchlistMinFailingDuration := flag.Duration("benchlist-min-failing-duration", 0, "how long queries to a peer must fail before it is benched (default: 5m)")
+	chaosScenario := flag.String("chaos", "", "chaos scenario run against the network once it is ready")
+	monitorChains := flag.Bool("monitor", false, "check that the nodes agree on and keep accepting blocks (always on with --chaos)")
+	monitorInterval := flag.Duration("monitor-interval", 5*time.Second, "how often the monitor queries every node")
+	monitorStall := flag.Duration("monitor-stall", time.Minute, "how long a chain may go without accepting a block before the monitor reports a liveness stall")
+	debugAPIs := flag.Bool("debug-apis", false, "enable the debug tracer APIs of the C-chain and the custom EVM chain and keep the state of every block, as the state command needs")
+	bootstrapTimeout := flag.Duration("bootstrap-timeout", manager.DefaultBootstrapTimeout, "how long every node may take to bootstrap before ava-sim gives up")
+	healthInterval := flag.Duration("health-interval", 30*time.Second, "how often the health of every node is checked once the network is ready (0 to disable)")
+	eventSink := flag.String("events", "", "also write lifecycle events as JSON lines to this file, unix:<socket> or tcp:<host:port>")
+	flag.Parse()
+	start := time.Now()
+
+	defaultPolicy, err := manager.ParseRestartPolicy(*restart)
+	if err != nil {
+		panic(err)
+	}
+	restartPolicies := make([]manager.RestartPolicy, constants.NumNodes)
+	for i := range restartPolicies {
+		restartPolicies[i] = defaultPolicy
+	}
+	if err := manager.ParseNodeRestartPolicies(*nodeRestart, restartPolicies); err != nil {
+		panic(err)
+	}
+	if len(*avalanchegoPath) > 0 {
+		if err := utils.CheckExecutable(*avalanchegoPath); err != nil {
+			panic(fmt.Sprintf("invalid avalanchego binary: %s", err))
+		}
+		color.Yellow("avalanchego binary set to: %s", *avalanchegoPath)
+	}
+	nodeBinaries, err := utils.ParseNodeAssignments(*nodeAvalanchegoPath)
+	if err != nil {
+		panic(fmt.Sprintf("invalid node avalanchego binaries: %s", err))
+	}
+	nodeAvalanchegoPaths := make([]string, constants.NumNodes)
+	for i, binary := range nodeBinaries {
+		if err := utils.CheckExecutable(binary); err != nil {
+			panic(fmt.Sprintf("invalid avalanchego binary for node%d: %s", i+1, err))
+		}
+		color.Yellow("node%d avalanchego binary set to: %s", i+1, binary)
+		nodeAvalanchegoPaths[i] = binary
+	}
+
+	var upgrades *upgrade.Config
+	if len(*upgradeFile) > 0 || len(*upgradeSchedule) > 0 {
+		if len(*avalanchegoPath) > 0 || len(nodeBinaries) > 0 {
+			panic("upgrade schedules are only supported for in-process nodes")
+		}
+		config := upgrade.Default
+		if len(*upgradeFile) > 0 {
+			config, err = manager.LoadUpgradeConfig(*upgradeFile)
+			if err != nil {
+				panic(fmt.Sprintf("invalid upgrade file: %s", err))
+			}
+		}
+		if err := manager.ParseUpgradeSchedule(*upgradeSchedule, start, &config); err != nil {
+			panic(fmt.Sprintf("invalid upgrade schedule: %s", err))
+		}
+		upgrades = &config
+	}
+
+	if *linkProxy {
+		if err := manager.CheckLinkProxyHosts(); err != nil {
+			panic(err)
+		}
+	}
+
+	var scenario *chaos.Scenario
+	if len(*chaosScenario) > 0 {
+		s, err := chaos.Load(*chaosScenario)
+		if err != nil {
+			panic(fmt.Sprintf("invalid chaos scenario: %s", err))
+		}
+		if !*linkProxy {
+			for _, step := range s.Steps {
+				switch step.Action {
+				case chaos.ActionPartition, chaos.ActionHeal, chaos.ActionLink, chaos.ActionResetLinks:
+					panic(fmt.Sprintf("chaos scenario uses %s, which requires --link-proxy", step.Action))
+				}
+			}
+		}
+		color.Yellow("chaos scenario set to: %s", *chaosScenario)
+		scenario = &s
+	}
+
 	var vm, vmGenesis string
 	var vmID ids.ID
-	switch len(os.Args) {
-	case 1: // normal network
-	case 4:
-		vm = path.Clean(os.Args[1])
+	switch flag.NArg() {
+	case 0: // normal network
+	case 3:
+		vm = path.Clean(flag.Arg(0))
 		if _, err := os.Stat(vm); os.IsNotExist(err) {
 			panic(fmt.Sprintf("%s does not exist", vm))
 		}
 		color.Yellow("vm set to: %s", vm)
 
-		vmGenesis = path.Clean(os.Args[2])
-		vmIDArg := os.Args[3]
+		vmGenesis = path.Clean(flag.Arg(1))
+		vmIDArg := flag.Arg(2)
 		var err error
 		if _, err := os.Stat(vmGenesis); os.IsNotExist(err) {
 			panic(fmt.Sprintf("%s does not exist", vmGenesis))
@@ -44,11 +218,116 @@ func main() {
 		panic("invalid arguments (expecting no arguments or [vm] [vm-genesis])")
 	}
 
+	// A ready file left behind by a previous run must not be mistaken for this
+	// network being ready
+	if err := os.Remove(*readyFile); err != nil && !os.IsNotExist(err) {
+		panic(err)
+	}
+
+	dir, err := ioutil.TempDir("", "ava-sim")
+	if err != nil {
+		panic(err)
+	}
+	color.Cyan("tmp dir located at: %s", dir)
+	info := manager.NewNetworkInfo(dir)
+	info.Upgrades = upgrades
+	eventLog, err := events.New(info.EventLog)
+	if err != nil {
+		panic(err)
+	}
+	if len(*eventSink) > 0 {
+		if err := eventLog.AddSink(*eventSink); err != nil {
+			panic(err)
+		}
+		color.Yellow("events written to: %s", *eventSink)
+	}
+	scrapeConfig := metrics.ScrapeConfig(fmt.Sprintf("127.0.0.1:%d", constants.APIPort))
+	if err := ioutil.WriteFile(info.PrometheusConfig, scrapeConfig, constants.FilePerms); err != nil {
+		panic(err)
+	}
+	network := manager.NewNetwork(manager.Config{
+		Dir:                  dir,
+		VMPath:               vm,
+		VMID:                 vmID,
+		RestartPolicies:      restartPolicies,
+		AvalancheGoPath:      *avalanchegoPath,
+		NodeAvalancheGoPaths: nodeAvalanchegoPaths,
+		Upgrades:             upgrades,
+		LinkProxy:            *linkProxy,
+		DebugAPIs:            *debugAPIs,
+		BootstrapTimeout:     *bootstrapTimeout,
+		Benchlist: manager.Benchlist{
+			FailThreshold:      *benchlistFailThreshold,
+			Duration:           *benchlistDuration,
+			MinFailingDuration: *benchlistMinFailingDuration,
+		},
+		Events: eventLog,
+	})
+
+	api := server.New(network, eventLog)
+	api.SetInfo(info)
+	writeReadyFile := func(info manager.NetworkInfo) error {
+		infoBytes, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := utils.WriteFileAtomic(*readyFile, infoBytes); err != nil {
+			return fmt.Errorf("could not write ready file: %w", err)
+		}
+		return nil
+	}
+	// Contracts deployed once the network is ready are added to the ready file
+	api.SetInfoWriter(writeReadyFile)
+	markReady := func(info manager.NetworkInfo) error {
+		if err := writeReadyFile(info); err != nil {
+			return err
+		}
+		api.SetReady(info)
+		color.Green("network ready (info written to %s)", *readyFile)
+		return nil
+	}
+
 	// Start local network
 	bootstrapped := make(chan struct{})
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 	g, gctx := errgroup.WithContext(ctx)
+	var mon *monitor.Monitor
+	// ready marks the network as ready, starts the background checks and then
+	// runs the chaos scenario, if any
+	ready := func(info manager.NetworkInfo) error {
+		if err := markReady(info); err != nil {
+			return err
+		}
+		if *healthInterval > 0 {
+			checker := health.NewChecker(info.Nodes, eventLog)
+			g.Go(func() error {
+				return checker.Run(gctx, *healthInterval)
+			})
+		}
+		if *monitorChains || scenario != nil {
+			var err error
+			mon, err = startMonitor(gctx, info, monitor.Config{
+				Interval:     *monitorInterval,
+				StallTimeout: *monitorStall,
+			}, eventLog)
+			if err != nil {
+				return err
+			}
+			api.SetMonitor(mon)
+			g.Go(func() error {
+				return mon.Run(gctx)
+			})
+		}
+		if scenario == nil {
+			return nil
+		}
+		if err := api.RunChaos(gctx, *scenario); err != nil && gctx.Err() == nil {
+			return fmt.Errorf("%w: %v", errChaos, err)
+		}
+		return nil
+	}
+	shutdownRequested := false
 	g.Go(func() error {
 		// register signals to kill the application
 		signals := make(chan os.Signal, 1)
@@ -63,6 +342,7 @@ func main() {
 		select {
 		case sig := <-signals:
 			color.Red("signal received: %v", sig)
+			shutdownRequested = true
 			cancel()
 		case <-gctx.Done():
 		}
@@ -70,23 +350,84 @@ func main() {
 	})
 
 	g.Go(func() error {
-		return manager.StartNetwork(gctx, vm, vmID, bootstrapped)
+		return api.Run(gctx)
+	})
+
+	g.Go(func() error {
+		return network.Start(gctx, bootstrapped)
 	})
 
 	// Only setup network if a custom VM is provided and the network has finished
 	// bootstrapping
 	select {
 	case <-bootstrapped:
-		if len(vm) > 0 && gctx.Err() == nil {
+		if gctx.Err() != nil {
+			break
+		}
+		if len(vm) == 0 {
 			g.Go(func() error {
-				err := runner.SetupSubnet(gctx, vmID, vmGenesis)
-				fmt.Println(err)
-				return err
+				return ready(info)
 			})
+			break
 		}
+		g.Go(func() error {
+			blockchainID, err := runner.SetupSubnet(gctx, network, vmID, vmGenesis, eventLog)
+			if err != nil {
+				if gctx.Err() == nil {
+					return fmt.Errorf("%w: %v", errSubnetSetup, err)
+				}
+				return nil
+			}
+			info.Subnet = &manager.SubnetInfo{
+				SubnetID:     constants.WhitelistedSubnets,
+				BlockchainID: blockchainID.String(),
+				VMID:         vmID.String(),
+			}
+			return ready(info)
+		})
 	case <-gctx.Done():
 	}
 
-	color.Red("ava-sim exited with error: %s", g.Wait())
-	os.Exit(1)
+	err = g.Wait()
+	os.Remove(*readyFile)
+	eventLog.Close()
+	exitCode := exitCodeFor(err, shutdownRequested)
+	if exitCode == exitCodeSuccess && mon != nil {
+		if err = mon.Err(); err != nil {
+			exitCode = monitorExitCode(err)
+		}
+	}
+	if exitCode == exitCodeSuccess {
+		color.Cyan("ava-sim shut down cleanly")
+	} else {
+		color.Red("ava-sim exited with error: %s", err)
+	}
+	os.Exit(exitCode)
+}
+
+// exitCodeFor maps the error the network stopped with to the process exit
+// code. A node crash takes precedence over the failure it may have caused.
+func exitCodeFor(err error, shutdownRequested bool) int {
+	switch {
+	case err == nil && shutdownRequested:
+		return exitCodeSuccess
+	case errors.Is(err, manager.ErrNodeCrashed):
+		return exitCodeNodeCrashed
+	case errors.Is(err, errSubnetSetup):
+		return exitCodeSubnetFailed
+	case errors.Is(err, errChaos):
+		return exitCodeChaosFailed
+	case errors.Is(err, manager.ErrBootstrap):
+		return exitCodeBootstrapFailed
+	default:
+		return exitCodeFailure
+	}
+}
+
+// monitorExitCode maps a finding of the monitor to the process exit code
+func monitorExitCode(err error) int {
+	if errors.Is(err, monitor.ErrSafetyViolation) {
+		return exitCodeSafetyViolation
+	}
+	return exitCodeLivenessStall
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/ava-sim/events"
+	"github.com/ava-labs/ava-sim/evm"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/monitor"
+	"github.com/ava-labs/ava-sim/runner"
+)
+
+// startMonitor creates a monitor of the primary network chains and, if it
+// serves JSON-RPC, the custom chain of [info]. Idle chains are probed with the
+// same txs as the readiness checks.
+func startMonitor(ctx context.Context, info manager.NetworkInfo, config monitor.Config, log *events.Log) (*monitor.Monitor, error) {
+	config.Probe = runner.ProbeChain
+	nodeURIs := make([]string, len(info.Nodes))
+	for i, node := range info.Nodes {
+		nodeURIs[i] = node.URI
+	}
+	chains := []monitor.Chain{{Name: "P"}, {Name: "X"}, {Name: "C"}}
+	if info.Subnet != nil {
+		blockchainID := info.Subnet.BlockchainID
+		if evm.IsEVM(ctx, nodeURIs[0], blockchainID) {
+			chains = append(chains, monitor.Chain{Name: blockchainID, EVM: true})
+		} else {
+			log.Record(monitor.EventMonitor, "%s is not an EVM chain and is not monitored", blockchainID)
+		}
+	}
+
+	m, err := monitor.New(config, nodeURIs, chains, log)
+	if err != nil {
+		return nil, fmt.Errorf("could not create monitor: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ava-labs/ava-sim/evm"
+	"github.com/ava-labs/ava-sim/load"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/ava-labs/libevm/ethclient"
+	"github.com/fatih/color"
+)
+
+// recordCommand writes the txs of a range of blocks of an EVM chain to a
+// recording that the replay command can send to another chain. Offsets have
+// block granularity: the txs of a block all get its timestamp.
+func recordCommand(args []string) int {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	target := addEVMTargetFlags(fs)
+	rpcURL := fs.String("rpc", "", "JSON-RPC endpoint of the chain to record, if it isn't a chain of the running network")
+	from := fs.String("from", "latest", "first block to record: a height or latest")
+	count := fs.Uint64("count", 0, "number of blocks to record (default: until interrupted)")
+	output := fs.String("output", "", "file to write the recording to")
+	raw := fs.Bool("raw", false, "record signed txs rather than their sender and call")
+	fs.Parse(args)
+
+	if len(*output) == 0 {
+		color.Red("--output must be set")
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	var (
+		client *ethclient.Client
+		err    error
+	)
+	if len(*rpcURL) > 0 {
+		client, err = ethclient.DialContext(ctx, *rpcURL)
+	} else {
+		client, err = target.dial(ctx, false)
+	}
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	defer client.Close()
+
+	var start uint64
+	if *from == "latest" {
+		block, err := evm.BlockByNumber(ctx, client.Client(), nil)
+		if err != nil {
+			color.Red("could not get the last accepted block: %s", err)
+			return 1
+		}
+		start = block.Number
+	} else if start, err = strconv.ParseUint(*from, 10, 64); err != nil {
+		color.Red("invalid block %q", *from)
+		return 1
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		color.Red("could not create output file: %s", err)
+		return 1
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+
+	var (
+		first    *time.Time
+		blocks   uint64
+		recorded int
+	)
+	// Blocks are streamed as headers only, their txs are read once accepted
+	err = evm.StreamBlocks(ctx, client.Client(), start, func(header evm.Block) error {
+		block, txs, err := evm.RecordBlock(ctx, client.Client(), header.Number, *raw)
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = &block.Time
+		}
+		for _, tx := range txs {
+			tx.OffsetMs = block.Time.Sub(*first).Milliseconds()
+			if err := encoder.Encode(tx); err != nil {
+				return err
+			}
+		}
+		recorded += len(txs)
+		blocks++
+		fmt.Printf("block %d: %d txs\n", block.Number, len(txs))
+		if *count > 0 && blocks >= *count {
+			return errCountReached
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errCountReached) {
+		color.Red("could not record blocks: %s", err)
+		return 1
+	}
+	color.Green("recorded %d txs from %d blocks to %s", recorded, blocks, *output)
+	return 0
+}
+
+// replayCommand sends the txs of a recording to an EVM chain, re-signed by
+// local keys, in order and at the pace they were recorded at
+func replayCommand(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	target := addEVMTargetFlags(fs)
+	input := fs.String("input", "", "recording to replay, as written by the record command")
+	keys := fs.String("keys", "", "keys replaying the txs of senders of the recording: <address>=<hex private key>,... (default: keys derived from the senders)")
+	speed := fs.Float64("speed", 1, "pace of the replay relative to the recording (0 sends txs as fast as possible)")
+	fund := fs.String("fund", "10", "AVAX the replaying keys are funded with (0 doesn't fund them)")
+	funderKey := fs.String("funder-key", "", "hex private key funding the replaying keys (default: the key funded in the local genesis)")
+	drain := fs.Duration("drain", 30*time.Second, "time the receipts of the sent txs are waited for")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	if len(*input) == 0 {
+		color.Red("--input must be set")
+		return 1
+	}
+	if *speed < 0 {
+		color.Red("the speed can't be negative")
+		return 1
+	}
+	file, err := os.Open(*input)
+	if err != nil {
+		color.Red("could not open recording: %s", err)
+		return 1
+	}
+	txs, err := evm.ReadRecording(file)
+	file.Close()
+	if err != nil {
+		color.Red("could not read recording: %s", err)
+		return 1
+	}
+
+	config := load.ReplayConfig{
+		Keys:  make(map[common.Address]*ecdsa.PrivateKey),
+		Speed: *speed,
+	}
+	if len(*keys) > 0 {
+		for _, mapping := range strings.Split(*keys, ",") {
+			parts := strings.SplitN(strings.TrimSpace(mapping), "=", 2)
+			if len(parts) != 2 || !common.IsHexAddress(parts[0]) {
+				color.Red("invalid key mapping %q: expected <address>=<hex private key>", mapping)
+				return 1
+			}
+			key, err := crypto.HexToECDSA(strings.TrimPrefix(parts[1], "0x"))
+			if err != nil {
+				color.Red("invalid key of %s: %s", parts[0], err)
+				return 1
+			}
+			config.Keys[common.HexToAddress(parts[0])] = key
+		}
+	}
+	funding, ok := new(big.Rat).SetString(*fund)
+	if !ok || funding.Sign() < 0 {
+		color.Red("invalid funding %q", *fund)
+		return 1
+	}
+	if funding.Sign() > 0 {
+		funding.Mul(funding, weiPerAVAX)
+		config.Funding = new(big.Int).Quo(funding.Num(), funding.Denom())
+		config.Funder = evm.FundedKey()
+		if len(*funderKey) > 0 {
+			key, err := crypto.HexToECDSA(strings.TrimPrefix(*funderKey, "0x"))
+			if err != nil {
+				color.Red("invalid funder key: %s", err)
+				return 1
+			}
+			config.Funder = key
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	uri, chain, err := target.resolve(ctx)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	config.RPCURL = evm.RPCURL(uri, chain)
+	replayer, err := load.NewReplayer(ctx, config, txs)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	defer replayer.Close()
+
+	color.Cyan("replaying %d txs from %d senders on %s", len(txs), replayer.Senders(), chain)
+	replayer.Run(ctx, func(i int) {
+		if !*jsonOutput && (i+1)%100 == 0 {
+			fmt.Printf("%d/%d txs\n", i+1, len(txs))
+		}
+	})
+
+	// Receipts are waited for unless interrupted again
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), *drain)
+	defer cancelDrain()
+	drainCtx, stopDrain := signal.NotifyContext(drainCtx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopDrain()
+	report := replayer.Wait(drainCtx)
+
+	if *jsonOutput {
+		reportBytes, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(reportBytes))
+		return 0
+	}
+	color.Green("%s", report)
+	for err, count := range report.Errors {
+		color.Red("%d× %s", count, err)
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/fatih/color"
+)
+
+// stateTableFormat lays out the tables of the state command
+const stateTableFormat = "%-44s %-10s %-10s %-10s %s\n"
+
+// stateSummary is the storage changed over the blocks reported by the state
+// command
+type stateSummary struct {
+	Blocks    int                                `json:"blocks"`
+	Txs       int                                `json:"txs"`
+	Total     evm.StorageDiff                    `json:"total"`
+	Contracts map[common.Address]evm.StorageDiff `json:"contracts"`
+}
+
+// stateCommand traces every tx of a range of blocks of an EVM chain and
+// reports the storage slots they created, modified and deleted per block and
+// per contract
+func stateCommand(args []string) int {
+	fs := flag.NewFlagSet("state", flag.ExitOnError)
+	target := addEVMTargetFlags(fs)
+	to := fs.String("to", "latest", "last block of the range: a height or latest")
+	count := fs.Uint64("count", 10, "number of blocks of the range, ending at --to")
+	top := fs.Int("top", 20, "number of contracts to print, by decreasing growth (0 prints all)")
+	format := fs.String("format", formatTable, "output format: table or json")
+	fs.Parse(args)
+
+	if *format != formatTable && *format != formatJSON {
+		color.Red("unknown format %q", *format)
+		return 1
+	}
+
+	ctx := context.Background()
+	client, err := target.dial(ctx, false)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	defer client.Close()
+
+	var last uint64
+	if *to == "latest" {
+		block, err := evm.BlockByNumber(ctx, client.Client(), nil)
+		if err != nil {
+			color.Red("could not get the last accepted block: %s", err)
+			return 1
+		}
+		last = block.Number
+	} else if last, err = strconv.ParseUint(*to, 10, 64); err != nil {
+		color.Red("invalid block %q", *to)
+		return 1
+	}
+	// The genesis block has no txs to trace
+	first := uint64(1)
+	if *count > 0 && last+1 > *count+first {
+		first = last + 1 - *count
+	}
+
+	var (
+		blocks  []evm.BlockStateDiff
+		summary = stateSummary{Contracts: make(map[common.Address]evm.StorageDiff)}
+	)
+	for number := first; number <= last; number++ {
+		block, err := evm.TraceStateDiff(ctx, client.Client(), number)
+		if err != nil {
+			color.Red("%s", err)
+			color.Yellow("blocks can only be traced if ava-sim was started with --debug-apis")
+			return 1
+		}
+		blocks = append(blocks, block)
+		summary.Blocks++
+		summary.Txs += block.TxCount
+		for address, diff := range block.Contracts {
+			contract := summary.Contracts[address]
+			contract.Add(diff)
+			summary.Contracts[address] = contract
+		}
+		summary.Total.Add(block.Total())
+	}
+
+	if *format == formatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, block := range blocks {
+			encoder.Encode(block)
+		}
+		encoder.Encode(map[string]stateSummary{"summary": summary})
+		return 0
+	}
+
+	fmt.Printf(stateTableFormat, "HEIGHT", "TXS", "CREATED", "MODIFIED", "DELETED")
+	for _, block := range blocks {
+		total := block.Total()
+		fmt.Printf(stateTableFormat,
+			strconv.FormatUint(block.Number, 10),
+			strconv.Itoa(block.TxCount),
+			strconv.Itoa(total.Created),
+			strconv.Itoa(total.Modified),
+			strconv.Itoa(total.Deleted),
+		)
+	}
+	fmt.Println()
+	fmt.Printf("%d blocks, %d txs: %d slots created, %d modified, %d deleted (growth %+d)\n",
+		summary.Blocks, summary.Txs, summary.Total.Created, summary.Total.Modified, summary.Total.Deleted, summary.Total.Growth())
+	if len(summary.Contracts) == 0 {
+		return 0
+	}
+
+	fmt.Println()
+	fmt.Printf(stateTableFormat, "CONTRACT", "CREATED", "MODIFIED", "DELETED", "GROWTH")
+	for i, address := range evm.SortContracts(summary.Contracts) {
+		if *top > 0 && i == *top {
+			fmt.Printf("... %d more contracts\n", len(summary.Contracts)-*top)
+			break
+		}
+		diff := summary.Contracts[address]
+		fmt.Printf(stateTableFormat,
+			address.Hex(),
+			strconv.Itoa(diff.Created),
+			strconv.Itoa(diff.Modified),
+			strconv.Itoa(diff.Deleted),
+			fmt.Sprintf("%+d", diff.Growth()),
+		)
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/fatih/color"
+)
+
+// timestampCommand prints the first block of an EVM chain accepted at or after
+// a time, or the time a block was accepted at
+func timestampCommand(args []string) int {
+	fs := flag.NewFlagSet("timestamp", flag.ExitOnError)
+	target := addEVMTargetFlags(fs)
+	at := fs.String("time", "", "time to find the first block at or after: RFC 3339 or unix seconds")
+	height := fs.String("block", "", "block to print the time of: a height or latest")
+	jsonOutput := fs.Bool("json", false, "print the block as JSON")
+	fs.Parse(args)
+
+	if (len(*at) == 0) == (len(*height) == 0) {
+		color.Red("exactly one of --time and --block must be set")
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, err := target.dial(ctx, false)
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	defer client.Close()
+
+	var (
+		block evm.Block
+		t     time.Time
+	)
+	if len(*at) > 0 {
+		if t, err = parseTime(*at); err != nil {
+			color.Red("%s", err)
+			return 1
+		}
+		if block, err = evm.BlockAtTime(ctx, client.Client(), t); err != nil {
+			color.Red("could not find a block at or after %s: %s", t.UTC().Format(time.RFC3339Nano), err)
+			return 1
+		}
+	} else {
+		var number *big.Int
+		if *height != "latest" {
+			n, err := strconv.ParseUint(*height, 10, 64)
+			if err != nil {
+				color.Red("invalid block %q", *height)
+				return 1
+			}
+			number = new(big.Int).SetUint64(n)
+		}
+		if block, err = evm.BlockByNumber(ctx, client.Client(), number); err != nil {
+			color.Red("could not get block %s: %s", *height, err)
+			return 1
+		}
+	}
+
+	if *jsonOutput {
+		blockBytes, _ := json.MarshalIndent(block, "", "  ")
+		fmt.Println(string(blockBytes))
+		return 0
+	}
+	fmt.Printf("block:     %d (%s)\n", block.Number, block.Hash.Hex())
+	fmt.Printf("time:      %s\n", block.Time.UTC().Format("2006-01-02T15:04:05.000Z"))
+	fmt.Printf("unix:      %.3f\n", float64(block.Time.UnixMilli())/1000)
+	if !t.IsZero() {
+		fmt.Printf("after:     %s\n", block.Time.Sub(t))
+	}
+	return 0
+}
+
+// parseTime parses [s] as an RFC 3339 time or as unix seconds, which may be
+// fractional
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected RFC 3339 or unix seconds", s)
+	}
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*float64(time.Second))), nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os/signal"
+	"syscall"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/server"
+	"github.com/ava-labs/ava-sim/tui"
+
+	"github.com/fatih/color"
+)
+
+// tuiCommand shows a dashboard of a running network
+func tuiCommand(args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	fs.Parse(args)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	client := server.NewClient(*endpoint)
+	infoCtx, infoCancel := context.WithTimeout(ctx, constants.HTTPTimeout)
+	info, err := client.Network(infoCtx)
+	infoCancel()
+	if err != nil {
+		color.Red("could not get the network info: %s", err)
+		return 1
+	}
+	if err := tui.New(client, info).Run(ctx); err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/runner"
+	"github.com/ava-labs/ava-sim/server"
+	"github.com/ava-labs/ava-sim/utils"
+
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/fatih/color"
+	"golang.org/x/sync/errgroup"
+)
+
+// probeInterval is the pause between the probes run while the nodes are rolled
+const probeInterval = 5 * time.Second
+
+// upgradeCommand rolls the nodes of a running network to another avalanchego
+// binary one at a time. The chains are probed through the nodes that are not
+// restarting for the whole roll, and through all of them after every node.
+func upgradeCommand(args []string) int {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	binary := fs.String("avalanchego-path", "", "avalanchego binary to roll the nodes to (required)")
+	nodesStr := fs.String("nodes", "", "comma separated nodes to upgrade, in order (default: all nodes)")
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API of the running network")
+	timeout := fs.Duration("timeout", 10*time.Minute, "how long each node gets to restart and finalize the probe txs")
+	probeTimeout := fs.Duration("probe-timeout", 2*time.Minute, "how long a probe gets to be finalized while a node restarts before the upgrade fails")
+	fs.Parse(args)
+
+	if len(*binary) == 0 {
+		color.Red("--avalanchego-path is required")
+		return 1
+	}
+	// The binary is checked before any node is stopped
+	if err := utils.CheckExecutable(*binary); err != nil {
+		color.Red("invalid avalanchego binary: %s", err)
+		return 1
+	}
+	client := server.NewClient(*endpoint)
+	network, err := client.Network(context.Background())
+	if err != nil {
+		color.Red("could not get network info: %s", err)
+		return 1
+	}
+	nodes, err := parseNodeList(*nodesStr, len(network.Nodes))
+	if err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	nodeURIs := make([]string, len(network.Nodes))
+	for i, node := range network.Nodes {
+		nodeURIs[i] = node.URI
+	}
+	blockchainID := ""
+	if network.Subnet != nil {
+		blockchainID = network.Subnet.BlockchainID
+	}
+
+	prober := &rollProber{
+		nodeURIs:     nodeURIs,
+		blockchainID: blockchainID,
+		timeout:      *probeTimeout,
+	}
+	rollCtx, stopProbing := context.WithCancel(context.Background())
+	defer stopProbing()
+	g, gctx := errgroup.WithContext(rollCtx)
+	g.Go(func() error {
+		return prober.run(gctx)
+	})
+	g.Go(func() error {
+		defer stopProbing()
+		for _, nodeNum := range nodes {
+			ctx, cancel := context.WithTimeout(gctx, *timeout)
+			err := upgradeNode(ctx, client, prober, network, nodeNum, *binary)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("upgrade of node%d failed: %w", nodeNum, err)
+			}
+			color.Green("node%d upgraded to %s and all chains are finalizing", nodeNum, *binary)
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		color.Red("%s", err)
+		return 1
+	}
+	color.Green("rolling upgrade complete")
+	return 0
+}
+
+func upgradeNode(
+	ctx context.Context,
+	client *server.Client,
+	prober *rollProber,
+	network manager.NetworkInfo,
+	nodeNum int,
+	binary string,
+) error {
+	prober.setDown(nodeNum)
+	if _, err := client.RestartNode(ctx, nodeNum, binary); err != nil {
+		return err
+	}
+
+	uri := network.Nodes[nodeNum-1].URI
+	chains := append([]string{}, constants.Chains...)
+	if len(prober.blockchainID) > 0 {
+		chains = append(chains, prober.blockchainID)
+	}
+	infoClient := info.NewClient(uri)
+	for _, chain := range chains {
+		for {
+			if bootstrapped, _ := infoClient.IsBootstrapped(ctx, chain); bootstrapped {
+				break
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("node%d did not bootstrap %s: %w", nodeNum, chain, ctx.Err())
+			}
+			color.Yellow("waiting for node%d to bootstrap %s", nodeNum, chain)
+			time.Sleep(waitPollFrequency)
+		}
+	}
+	prober.setDown(0)
+	return prober.probe(ctx)
+}
+
+// rollProber probes the chains through the nodes that are not restarting.
+// Probes are serialized since they are all issued by the same keys.
+type rollProber struct {
+	nodeURIs     []string
+	blockchainID string
+	timeout      time.Duration
+
+	// lock is held for the whole of every probe, so that a node is only
+	// stopped once no probe waits on it
+	lock sync.Mutex
+	// down is the number of the node restarting, or 0 if none is
+	down int
+}
+
+// run probes the chains every [probeInterval] until [ctx] is cancelled, and
+// fails if a probe is not finalized within [rollProber.timeout]
+func (p *rollProber) run(ctx context.Context) error {
+	for {
+		select {
+		case <-time.After(probeInterval):
+		case <-ctx.Done():
+			return nil
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		err := p.probe(probeCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("chains stopped finalizing during the upgrade: %w", err)
+		}
+	}
+}
+
+// probe issues a probe on every chain and waits for the nodes that are not
+// restarting to accept it
+func (p *rollProber) probe(ctx context.Context) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	uris := make([]string, 0, len(p.nodeURIs))
+	for i, uri := range p.nodeURIs {
+		if i+1 != p.down {
+			uris = append(uris, uri)
+		}
+	}
+	return runner.ProbeChains(ctx, uris[0], uris, p.blockchainID)
+}
+
+// setDown marks [nodeNum] as restarting, or no node if it is 0, once the probe
+// in flight returned
+func (p *rollProber) setDown(nodeNum int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.down = nodeNum
+}
+
+// parseNodeList parses a comma separated list of node numbers, defaulting to
+// all [numNodes] nodes
+func parseNodeList(s string, numNodes int) ([]int, error) {
+	if len(s) == 0 {
+		nodes := make([]int, numNodes)
+		for i := range nodes {
+			nodes[i] = i + 1
+		}
+		return nodes, nil
+	}
+	var nodes []int
+	for _, nodeStr := range strings.Split(s, ",") {
+		nodeNum, err := strconv.Atoi(strings.TrimSpace(nodeStr))
+		if err != nil || nodeNum < 1 || nodeNum > numNodes {
+			return nil, fmt.Errorf("invalid node %q", nodeStr)
+		}
+		nodes = append(nodes, nodeNum)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/server"
+	"github.com/fatih/color"
+)
+
+const waitPollFrequency = time.Second
+
+// waitCommand blocks until the network started by another ava-sim process is
+// ready and prints its info
+func waitCommand(args []string) int {
+	fs := flag.NewFlagSet("wait", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 5*time.Minute, "how long to wait for the network to be ready")
+	endpoint := fs.String("endpoint", constants.APIURL, "ava-sim API to poll")
+	readyFile := fs.String("ready-file", "", "wait for this ready file instead of polling the API")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client := server.NewClient(*endpoint)
+	ticker := time.NewTicker(waitPollFrequency)
+	defer ticker.Stop()
+	for {
+		var (
+			info  *manager.NetworkInfo
+			ready bool
+		)
+		if len(*readyFile) > 0 {
+			info, ready = readReadyFile(*readyFile)
+		} else if reply, err := client.Ready(ctx); err == nil {
+			info, ready = reply.Network, reply.Ready
+		}
+		if ready {
+			infoBytes, _ := json.MarshalIndent(info, "", "  ")
+			fmt.Println(string(infoBytes))
+			return 0
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			color.Red("network not ready after %s", *timeout)
+			return 1
+		}
+	}
+}
+
+func readReadyFile(path string) (*manager.NetworkInfo, bool) {
+	infoBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	info := &manager.NetworkInfo{}
+	if err := json.Unmarshal(infoBytes, info); err != nil {
+		return nil, false
+	}
+	return info, true
+}
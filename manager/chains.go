@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+// bootstrapPollFrequency is how often a restarted node is checked for being
+// bootstrapped
+const bootstrapPollFrequency = time.Second
+
+// evmChainConfig is the config of the EVM chains of the network started with
+// [Config.DebugAPIs]. On top of the default APIs it enables the debug tracers,
+// and it keeps the state of every block so that the txs of any block can be
+// traced.
+var evmChainConfig = []byte(`{
+  "eth-apis": [
+    "eth",
+    "eth-filter",
+    "net",
+    "web3",
+    "internal-eth",
+    "internal-blockchain",
+    "internal-transaction",
+    "debug-tracer"
+  ],
+  "pruning-enabled": false
+}
+`)
+
+func chainConfigDir(dir string) string {
+	return fmt.Sprintf("%s/chains", dir)
+}
+
+// writeChainConfig writes the config of [chain], an alias or a blockchain ID,
+// where nodes look it up when they start
+func writeChainConfig(dir string, chain string, config []byte) error {
+	chainDir := fmt.Sprintf("%s/%s", chainConfigDir(dir), chain)
+	if err := os.MkdirAll(chainDir, os.FileMode(constants.FilePerms)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fmt.Sprintf("%s/config.json", chainDir), config, os.FileMode(constants.FilePerms))
+}
+
+// EnableDebugAPIs configures the EVM chain [chainID] with the debug APIs
+// enabled, if the network was started with [Config.DebugAPIs]. Nodes only read
+// chain configs as they start, so they are restarted one at a time once the
+// config is written. It must be called before the chain is created.
+func (n *Network) EnableDebugAPIs(ctx context.Context, chainID ids.ID) error {
+	if !n.config.DebugAPIs {
+		return nil
+	}
+	if err := writeChainConfig(n.config.Dir, chainID.String(), evmChainConfig); err != nil {
+		return err
+	}
+
+	nodeURLs := NodeURLs()
+	for nodeNum := 1; nodeNum <= constants.NumNodes; nodeNum++ {
+		if err := n.RestartNode(nodeNum, ""); err != nil {
+			return err
+		}
+		if err := waitNodeBootstrapped(ctx, nodeURLs[nodeNum-1], n.bootstrapTimeout()); err != nil {
+			return fmt.Errorf("node%d: %w", nodeNum, err)
+		}
+	}
+	return nil
+}
+
+// waitNodeBootstrapped waits for the node at [uri] to bootstrap the primary
+// network chains
+func waitNodeBootstrapped(ctx context.Context, uri string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := info.NewClient(uri)
+	for _, chain := range constants.Chains {
+		for {
+			if bootstrapped, _ := client.IsBootstrapped(ctx, chain); bootstrapped {
+				break
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: %s-chain not bootstrapped: %v", ErrBootstrap, chain, ctx.Err())
+			}
+			time.Sleep(bootstrapPollFrequency)
+		}
+	}
+	return nil
+}
@@ -8,16 +8,27 @@ import (
 
 	"github.com/ava-labs/avalanchego/config"
 	"github.com/ava-labs/avalanchego/config/node"
+	"github.com/ava-labs/avalanchego/upgrade"
 )
 
-func createNodeConfig(pluginDir string, args []string) (node.Config, error) {
+// createNodeConfig parses [args] into the config of an in-process node. The
+// upgrade schedule is set directly rather than through --upgrade-file, which
+// avalanchego refuses on the local network.
+func createNodeConfig(args []string, upgrades *upgrade.Config) (node.Config, error) {
 	fs := config.BuildFlagSet()
 	v, err := config.BuildViper(fs, args)
 	if err != nil {
 		return node.Config{}, err
 	}
 
-	return config.GetNodeConfig(v)
+	nodeConfig, err := config.GetNodeConfig(v)
+	if err != nil {
+		return node.Config{}, err
+	}
+	if upgrades != nil {
+		nodeConfig.UpgradeConfig = *upgrades
+	}
+	return nodeConfig, nil
 }
 
 // Flags represents available CLI flags when starting a node
@@ -71,6 +82,7 @@ type Flags struct {
 	StakingEnabled        bool
 	StakeMintingPeriod    string
 	StakingPort           uint
+	StakingHost           string
 	StakingDisabledWeight int
 	StakingTLSKeyFile     string
 	StakingTLSCertFile    string
@@ -87,6 +99,10 @@ type Flags struct {
 	ConfigFile     string
 	ChainConfigDir string
 
+	// Plugins and chain data
+	PluginDir    string
+	ChainDataDir string
+
 	// IPCS
 	IPCSChainIDs string
 
@@ -109,6 +125,7 @@ type Flags struct {
 	NetworkHealthMinConnPeers               int
 	NetworkTimeoutCoefficient               int
 	NetworkTimeoutHalflife                  string
+	NetworkMaxReconnectDelay                string
 
 	// Peer List Gossiping
 	NetworkPeerListGossipFrequency string
@@ -248,15 +265,22 @@ func flagsToArgs(flags Flags) []string {
 		"--log-dir=" + flags.LogDir,
 		"--log-display-level=" + flags.LogDisplayLevel,
 		"--staking-port=" + stakingPortString,
+		"--staking-host=" + flags.StakingHost,
 		"--staking-tls-key-file=" + stakerKeyFile,
 		"--staking-tls-cert-file=" + stakerCertFile,
 		"--track-subnets=" + flags.WhitelistedSubnets,
 		"--api-health-enabled=" + strconv.FormatBool(flags.APIHealthEnabled),
 		"--config-file=" + flags.ConfigFile,
 		"--chain-config-dir=" + flags.ChainConfigDir,
+		"--plugin-dir=" + flags.PluginDir,
+		"--chain-data-dir=" + flags.ChainDataDir,
 		"--api-info-enabled=" + strconv.FormatBool(flags.APIInfoEnabled),
 		"--index-enabled=" + strconv.FormatBool(flags.IndexEnabled),
 		"--db-type=" + flags.DBType,
+		"--network-max-reconnect-delay=" + flags.NetworkMaxReconnectDelay,
+		"--benchlist-fail-threshold=" + strconv.Itoa(flags.BenchlistFailThreshold),
+		"--benchlist-duration=" + flags.BenchlistDuration,
+		"--benchlist-min-failing-duration=" + flags.BenchlistMinFailingDuration,
 	}
 	args = removeEmptyFlags(args)
 
@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/ava-sim/constants"
+
+	"github.com/ava-labs/avalanchego/upgrade"
+)
+
+// NetworkInfo describes a running network so that external tooling can find
+// its nodes and chains without scraping stdout
+type NetworkInfo struct {
+	Dir string `json:"dir"`
+	// EventLog is the file events are written to as JSON lines
+	EventLog string `json:"eventLog"`
+	// PrometheusConfig is a Prometheus config scraping the metrics of every
+	// node from the ava-sim API
+	PrometheusConfig string      `json:"prometheusConfig"`
+	Nodes            []NodeInfo  `json:"nodes"`
+	Subnet           *SubnetInfo `json:"subnet,omitempty"`
+	// Upgrades is the upgrade schedule of the nodes when it differs from the
+	// default local network one
+	Upgrades *upgrade.Config `json:"upgrades,omitempty"`
+	// Contracts lists the contracts deployed with deploy-contract
+	Contracts []ContractInfo `json:"contracts,omitempty"`
+}
+
+// NodeInfo describes a single node of the network
+type NodeInfo struct {
+	ID     string `json:"id"`
+	URI    string `json:"uri"`
+	LogDir string `json:"logDir"`
+}
+
+// SubnetInfo describes the subnet and blockchain created for a custom VM
+type SubnetInfo struct {
+	SubnetID     string `json:"subnetID"`
+	BlockchainID string `json:"blockchainID"`
+	VMID         string `json:"vmID"`
+}
+
+// ContractInfo describes a contract deployed on an EVM chain of the network
+type ContractInfo struct {
+	Name string `json:"name"`
+	// Chain is the alias or blockchain ID of the chain the contract lives on
+	Chain    string `json:"chain"`
+	Address  string `json:"address"`
+	TxHash   string `json:"txHash"`
+	Deployer string `json:"deployer"`
+	Block    uint64 `json:"block"`
+}
+
+// NewNetworkInfo returns the info of a network whose data lives in [dir]
+func NewNetworkInfo(dir string) NetworkInfo {
+	var (
+		nodeURLs = NodeURLs()
+		nodeIDs  = NodeIDs()
+	)
+	nodes := make([]NodeInfo, constants.NumNodes)
+	for i := range nodes {
+		nodes[i] = NodeInfo{
+			ID:     nodeIDs[i],
+			URI:    nodeURLs[i],
+			LogDir: fmt.Sprintf("%s/logs", nodeDataDir(dir, i)),
+		}
+	}
+	return NetworkInfo{
+		Dir:              dir,
+		EventLog:         fmt.Sprintf("%s/events.jsonl", dir),
+		PrometheusConfig: fmt.Sprintf("%s/prometheus.yml", dir),
+		Nodes:            nodes,
+	}
+}
+
+func nodeDataDir(dir string, nodeNum int) string {
+	return fmt.Sprintf("%s/node%d", dir, nodeNum+1)
+}
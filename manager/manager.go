@@ -2,27 +2,55 @@ package manager
 
 import (
 	"context"
+	"crypto/tls"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/events"
+	"github.com/ava-labs/ava-sim/proxy"
 	"github.com/ava-labs/ava-sim/utils"
 
 	"github.com/ava-labs/avalanchego/api/info"
-	"github.com/ava-labs/avalanchego/app"
-	"github.com/ava-labs/avalanchego/config/node"
 	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/upgrade"
 	"github.com/fatih/color"
 	"golang.org/x/sync/errgroup"
 )
 
+// DefaultBootstrapTimeout is how long nodes may take to bootstrap unless
+// [Config.BootstrapTimeout] is set
+const DefaultBootstrapTimeout = 5 * time.Minute
+
 const (
 	bootstrapID = "NodeID-7Xhw2mDxuDS44j42TCB6U5579esbSt3Lg"
 	bootstrapIP = "127.0.0.1:9651"
 	waitDiff    = 10 * time.Second
+
+	// linkProxyReconnectDelay caps how long nodes wait before reconnecting to
+	// a peer, so links come back quickly once a fault is removed
+	linkProxyReconnectDelay = "5s"
+)
+
+var (
+	// ErrNodeStart is returned when a node cannot be created
+	ErrNodeStart = errors.New("node failed to start")
+	// ErrBootstrap is returned when the network does not finish bootstrapping
+	// within [Config.BootstrapTimeout]
+	ErrBootstrap = errors.New("network failed to bootstrap")
+	// ErrNodeCrashed is returned when a node exits while the network is
+	// supposed to be running
+	ErrNodeCrashed = errors.New("node crashed")
+	// ErrNoLinkProxy is returned when injecting link faults into a network
+	// started without [Config.LinkProxy]
+	ErrNoLinkProxy = errors.New("network was started without --link-proxy")
 )
 
 // Embed certs in binary and write to tmp file on startup (full binary)
@@ -88,12 +116,70 @@ func NodeURLs() []string {
 	return urls
 }
 
-func StartNetwork(ctx context.Context, vmPath string, vmID ids.ID, bootstrapped chan struct{}) error {
-	dir, err := ioutil.TempDir("", "ava-sim")
-	if err != nil {
-		panic(err)
-	}
-	color.Cyan("tmp dir located at: %s", dir)
+// Config describes the network to start
+type Config struct {
+	// Dir holds the data of every node
+	Dir    string
+	VMPath string
+	VMID   ids.ID
+	// AvalancheGoPath, if set, is the avalanchego binary every node is run
+	// with as a separate process. Otherwise nodes run inside ava-sim.
+	AvalancheGoPath string
+	// NodeAvalancheGoPaths is indexed by node number - 1 and overrides
+	// [AvalancheGoPath] for the nodes it is set for
+	NodeAvalancheGoPaths []string
+	// RestartPolicies is indexed by node number - 1. Nodes without a policy
+	// are never restarted.
+	RestartPolicies []RestartPolicy
+	// Upgrades, if set, replaces the default local network upgrade schedule.
+	// It is only supported for in-process nodes, as avalanchego refuses
+	// upgrade files on the local network.
+	Upgrades *upgrade.Config
+	// LinkProxy places a proxy in front of the staking port of every node so
+	// that faults can be injected on the links between nodes
+	LinkProxy bool
+	// Benchlist overrides the benchlist settings of every node where set
+	Benchlist Benchlist
+	// DebugAPIs enables the debug tracer APIs of the EVM chains and disables
+	// their pruning
+	DebugAPIs bool
+	// BootstrapTimeout is how long every node may take to bootstrap, or
+	// [DefaultBootstrapTimeout] if it is 0
+	BootstrapTimeout time.Duration
+	// Events, if set, records the lifecycle of the nodes
+	Events *events.Log
+}
+
+// Benchlist configures when nodes stop querying peers that keep failing to
+// respond
+type Benchlist struct {
+	// FailThreshold is the number of consecutive failed queries before a
+	// peer is benched
+	FailThreshold int
+	// Duration is the longest a peer stays benched
+	Duration time.Duration
+	// MinFailingDuration is how long queries to a peer must have been failing
+	// before it is benched
+	MinFailingDuration time.Duration
+}
+
+// Network is a local network of avalanchego nodes
+type Network struct {
+	config Config
+
+	lock  sync.RWMutex
+	nodes []*nodeRunner
+	links *proxy.Network
+}
+
+func NewNetwork(config Config) *Network {
+	return &Network{config: config}
+}
+
+// Start runs the network until [ctx] is cancelled or a node exits without
+// being restarted. [bootstrapped] is closed once all nodes are bootstrapped.
+func (n *Network) Start(ctx context.Context, bootstrapped chan struct{}) error {
+	dir := n.config.Dir
 	defer func() {
 		color.Cyan("tmp dir located at: %s", dir)
 	}()
@@ -104,15 +190,33 @@ func StartNetwork(ctx context.Context, vmPath string, vmID ids.ID, bootstrapped
 		panic(err)
 	}
 
+	vmPath := n.config.VMPath
 	if len(vmPath) > 0 {
-		if err := utils.CopyFile(vmPath, fmt.Sprintf("%s/%s", pluginsDir, vmID.String())); err != nil {
+		if err := utils.CopyFile(vmPath, fmt.Sprintf("%s/%s", pluginsDir, n.config.VMID.String())); err != nil {
 			panic(err)
 		}
 	}
 
-	nodeConfigs := make([]node.Config, constants.NumNodes)
+	if n.config.Upgrades != nil {
+		upgradeBytes, err := json.MarshalIndent(n.config.Upgrades, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(fmt.Sprintf("%s/upgrade.json", dir), upgradeBytes, os.FileMode(constants.FilePerms)); err != nil {
+			return err
+		}
+		printUpgradeSchedule(*n.config.Upgrades)
+	}
+	if n.config.DebugAPIs {
+		if err := writeChainConfig(dir, "C", evmChainConfig); err != nil {
+			return err
+		}
+	}
+
+	nodeIDs := NodeIDs()
+	nodes := make([]*nodeRunner, constants.NumNodes)
 	for i := 0; i < constants.NumNodes; i++ {
-		nodeDir := fmt.Sprintf("%s/node%d", dir, i+1)
+		nodeDir := nodeDataDir(dir, i)
 		if err := os.MkdirAll(nodeDir, os.FileMode(constants.FilePerms)); err != nil {
 			panic(err)
 		}
@@ -143,6 +247,20 @@ func StartNetwork(ctx context.Context, vmPath string, vmID ids.ID, bootstrapped
 			df.BootstrapIPs = ""
 			df.BootstrapIDs = ""
 		}
+		if n.config.Benchlist.FailThreshold > 0 {
+			df.BenchlistFailThreshold = n.config.Benchlist.FailThreshold
+		}
+		if n.config.Benchlist.Duration > 0 {
+			df.BenchlistDuration = n.config.Benchlist.Duration.String()
+		}
+		if n.config.Benchlist.MinFailingDuration > 0 {
+			df.BenchlistMinFailingDuration = n.config.Benchlist.MinFailingDuration.String()
+		}
+		if n.config.LinkProxy {
+			// The proxy takes the advertised staking address over
+			df.StakingHost = linkProxyNodeHost(i)
+			df.NetworkMaxReconnectDelay = linkProxyReconnectDelay
+		}
 
 		if len(vmPath) > 0 {
 			df.WhitelistedSubnets = constants.WhitelistedSubnets
@@ -151,31 +269,186 @@ func StartNetwork(ctx context.Context, vmPath string, vmID ids.ID, bootstrapped
 		df.StakingTLSKeyFile = keyFile
 		df.StakingSignerKeyFile = signerFile
 
-		nodeConfig, err := createNodeConfig(pluginsDir, flagsToArgs(df))
-		if err != nil {
-			panic(err)
+		df.PluginDir = pluginsDir
+		df.ChainConfigDir = chainConfigDir(dir)
+		df.ChainDataDir = fmt.Sprintf("%s/chaindata", nodeDir)
+
+		nodes[i] = newNodeRunner(i, nodeIDs[i], nodeDir, flagsToArgs(df), n.nodeBinary(i), n.nodePolicy(i), n.config.Upgrades, n.config.Events)
+	}
+	var links *proxy.Network
+	if n.config.LinkProxy {
+		proxyNodes := make([]proxy.Node, constants.NumNodes)
+		for i := range proxyNodes {
+			cert, err := tls.X509KeyPair(nodeCerts[i], nodeKeys[i])
+			if err != nil {
+				return err
+			}
+			stakingPort := constants.BaseHTTPPort + 2*i + 1
+			proxyNodes[i] = proxy.Node{
+				Cert:       cert,
+				ListenAddr: fmt.Sprintf("127.0.0.1:%d", stakingPort),
+				NodeAddr:   fmt.Sprintf("%s:%d", linkProxyNodeHost(i), stakingPort),
+			}
+		}
+		links = proxy.New(proxyNodes)
+		if err := links.Start(ctx); err != nil {
+			return fmt.Errorf("%w: %v", ErrNodeStart, err)
 		}
-		nodeConfig.PluginDir = pluginsDir
-		nodeConfig.ChainDataDir = fmt.Sprintf("%s/chaindata", nodeDir)
-		nodeConfigs[i] = nodeConfig
+		defer links.Stop()
+		color.Cyan("link proxies started")
 	}
 
+	n.lock.Lock()
+	n.nodes = nodes
+	n.links = links
+	n.lock.Unlock()
+
 	// Start all nodes and check if bootstrapped
+	for i, r := range nodes {
+		if err := r.start(); err != nil {
+			n.stopNodes(nodes[:i])
+			return err
+		}
+	}
+
 	g, gctx := errgroup.WithContext(ctx)
-	for i, config := range nodeConfigs {
-		c := config
-		j := i
+	for _, r := range nodes {
+		r := r
 		g.Go(func() error {
-			return runApp(g, gctx, j, c)
+			return r.supervise(gctx)
 		})
 	}
 	g.Go(func() error {
-		return checkBootstrapped(gctx, bootstrapped)
+		return checkBootstrapped(gctx, bootstrapped, n.bootstrapTimeout(), n.config.Events)
 	})
-	return g.Wait()
+
+	// Nodes are only stopped once the network is shutting down, either because
+	// [ctx] was cancelled or because one of the routines above failed.
+	<-gctx.Done()
+	n.stopNodes(nodes)
+	if err := g.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// Status returns the status of every node, or nothing if the network hasn't
+// been started yet
+func (n *Network) Status() []NodeStatus {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	statuses := make([]NodeStatus, len(n.nodes))
+	for i, r := range n.nodes {
+		statuses[i] = r.status()
+	}
+	return statuses
+}
+
+// RestartNode stops node [nodeNum] (starting at 1) and starts it again, with
+// the avalanchego binary at [binary] if it is set
+func (n *Network) RestartNode(nodeNum int, binary string) error {
+	r, err := n.node(nodeNum)
+	if err != nil {
+		return err
+	}
+	return r.restart(binary)
 }
 
-func checkBootstrapped(ctx context.Context, bootstrapped chan struct{}) error {
+// StopNode stops node [nodeNum] (starting at 1) until [StartNode] is called
+func (n *Network) StopNode(nodeNum int) error {
+	r, err := n.node(nodeNum)
+	if err != nil {
+		return err
+	}
+	return r.pause()
+}
+
+// StartNode starts node [nodeNum] (starting at 1) after [StopNode]
+func (n *Network) StartNode(nodeNum int) error {
+	r, err := n.node(nodeNum)
+	if err != nil {
+		return err
+	}
+	return r.resume()
+}
+
+func (n *Network) node(nodeNum int) (*nodeRunner, error) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	if nodeNum < 1 || nodeNum > len(n.nodes) {
+		return nil, fmt.Errorf("unknown node%d", nodeNum)
+	}
+	return n.nodes[nodeNum-1], nil
+}
+
+// Links returns the proxies placed between the nodes, through which faults are
+// injected
+func (n *Network) Links() (*proxy.Network, error) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	if n.links == nil {
+		return nil, ErrNoLinkProxy
+	}
+	return n.links, nil
+}
+
+func (n *Network) nodeBinary(nodeNum int) string {
+	if nodeNum < len(n.config.NodeAvalancheGoPaths) && len(n.config.NodeAvalancheGoPaths[nodeNum]) > 0 {
+		return n.config.NodeAvalancheGoPaths[nodeNum]
+	}
+	return n.config.AvalancheGoPath
+}
+
+func (n *Network) nodePolicy(nodeNum int) RestartPolicy {
+	if nodeNum < len(n.config.RestartPolicies) {
+		return n.config.RestartPolicies[nodeNum]
+	}
+	return RestartPolicy{Mode: RestartNever}
+}
+
+// stopNodes stops the nodes one at a time in reverse start order, so the
+// bootstrap node goes down last, and waits for each of them to exit.
+func (n *Network) stopNodes(nodes []*nodeRunner) {
+	for i := len(nodes) - 1; i >= 0; i-- {
+		nodes[i].stop()
+	}
+}
+
+// linkProxyNodeHost is the loopback address the staking port of node
+// [nodeNum] is moved to when it is proxied
+func linkProxyNodeHost(nodeNum int) string {
+	return fmt.Sprintf("127.0.1.%d", nodeNum+1)
+}
+
+// CheckLinkProxyHosts returns an error if the addresses the staking ports are
+// moved to with the link proxy can't be bound, as on systems other than Linux
+// where only 127.0.0.1 is a loopback address by default
+func CheckLinkProxyHosts() error {
+	for i := 0; i < constants.NumNodes; i++ {
+		host := linkProxyNodeHost(i)
+		l, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+		if err != nil {
+			return fmt.Errorf(
+				"--link-proxy needs %s to be a loopback address (e.g. sudo ifconfig lo0 alias %s up on macOS): %w",
+				host, host, err,
+			)
+		}
+		l.Close()
+	}
+	return nil
+}
+
+func (n *Network) bootstrapTimeout() time.Duration {
+	if n.config.BootstrapTimeout > 0 {
+		return n.config.BootstrapTimeout
+	}
+	return DefaultBootstrapTimeout
+}
+
+func checkBootstrapped(ctx context.Context, bootstrapped chan struct{}, timeout time.Duration, log *events.Log) error {
 	if bootstrapped == nil {
 		return nil
 	}
@@ -185,13 +458,18 @@ func checkBootstrapped(ctx context.Context, bootstrapped chan struct{}) error {
 		nodeIDs  = NodeIDs()
 	)
 
+	deadline := time.Now().Add(timeout)
 	for i, url := range nodeURLs {
 		client := info.NewClient(url)
+		reported := make(map[string]bool)
 		for {
 			if ctx.Err() != nil {
 				color.Red("stopping bootstrapped check: %v", ctx.Err())
 				return ctx.Err()
 			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%w: %s not ready after %s", ErrBootstrap, nodeIDs[i], timeout)
+			}
 			bootstrapped := true
 			for _, chain := range constants.Chains {
 				chainBootstrapped, _ := client.IsBootstrapped(ctx, chain)
@@ -200,6 +478,16 @@ func checkBootstrapped(ctx context.Context, bootstrapped chan struct{}) error {
 					bootstrapped = false
 					break
 				}
+				if !reported[chain] {
+					reported[chain] = true
+					log.Emit(events.Event{
+						Type:    events.NodeBootstrapped,
+						Message: fmt.Sprintf("%s bootstrapped %s-chain", nodeIDs[i], chain),
+						Node:    i + 1,
+						NodeID:  nodeIDs[i],
+						Chain:   chain,
+					})
+				}
 			}
 			if !bootstrapped {
 				time.Sleep(waitDiff)
@@ -210,6 +498,12 @@ func checkBootstrapped(ctx context.Context, bootstrapped chan struct{}) error {
 				time.Sleep(waitDiff)
 				continue
 			}
+			log.Emit(events.Event{
+				Type:    events.PeersConnected,
+				Message: fmt.Sprintf("%s connected to all peers", nodeIDs[i]),
+				Node:    i + 1,
+				NodeID:  nodeIDs[i],
+			})
 			color.Cyan("%s is bootstrapped and connected", nodeIDs[i])
 			break
 		}
@@ -222,28 +516,14 @@ func checkBootstrapped(ctx context.Context, bootstrapped chan struct{}) error {
 	color.Green("standard VM endpoints now accessible at:")
 	for i, url := range nodeURLs {
 		color.Green("%s: %s", nodeIDs[i], url)
+		log.Emit(events.Event{
+			Type:    events.EndpointsReady,
+			Message: fmt.Sprintf("standard VM endpoints of %s accessible at %s", nodeIDs[i], url),
+			Node:    i + 1,
+			NodeID:  nodeIDs[i],
+			URI:     url,
+		})
 	}
 
 	return nil
 }
-
-func runApp(g *errgroup.Group, ctx context.Context, nodeNum int, config node.Config) error {
-	app, err := app.New(config)
-	if err != nil {
-		return fmt.Errorf("node%d failed to start: %w", nodeNum+1, err)
-	}
-
-	// Start running the AvalancheGo application
-	app.Start()
-	g.Go(func() error {
-		<-ctx.Done()
-		app.Stop()
-		return ctx.Err()
-	})
-
-	exitCode := app.ExitCode()
-	if (exitCode > 0 || err != nil) && ctx.Err() == nil {
-		color.Red("node%d exited with code %d: %v", nodeNum+1, exitCode, err)
-	}
-	return err
-}
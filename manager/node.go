@@ -0,0 +1,363 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/events"
+	"github.com/ava-labs/ava-sim/utils"
+
+	"github.com/ava-labs/avalanchego/app"
+	"github.com/ava-labs/avalanchego/upgrade"
+	"github.com/fatih/color"
+)
+
+const (
+	restartDelay  = 5 * time.Second
+	crashLogLines = 20
+)
+
+var (
+	errNetworkStopped = errors.New("network is stopped")
+	errNodeBusy       = errors.New("node is being restarted or is stopped")
+	errNodeNotPaused  = errors.New("node is not stopped")
+	// errUpgradeSchedule is returned when switching a node running a custom
+	// upgrade schedule to a binary, which couldn't be given the schedule
+	errUpgradeSchedule = errors.New("custom upgrade schedules require in-process nodes")
+)
+
+// NodeStatus reports the state of a node and the crashes it went through
+type NodeStatus struct {
+	Node    int    `json:"node"`
+	ID      string `json:"id"`
+	Running bool   `json:"running"`
+	// Stopped is set while the node is stopped on purpose
+	Stopped bool `json:"stopped,omitempty"`
+	// AvalancheGoPath is the binary the node runs with, or empty when it runs
+	// in-process
+	AvalancheGoPath string        `json:"avalanchegoPath,omitempty"`
+	Policy          RestartPolicy `json:"restartPolicy"`
+	Restarts        int           `json:"restarts"`
+	Crashes         []Crash       `json:"crashes"`
+}
+
+// Crash records a node exiting while the network was running
+type Crash struct {
+	Time     time.Time `json:"time"`
+	ExitCode int       `json:"exitCode"`
+	// LastLogLines are the last lines of the node's main log (and of its
+	// stderr when it runs as a separate process) at crash time
+	LastLogLines []string `json:"lastLogLines"`
+}
+
+// nodeRunner runs a single node and restarts it according to its policy
+type nodeRunner struct {
+	nodeNum int
+	id      string
+	nodeDir string
+	// args are the CLI flags the node is started with
+	args   []string
+	policy RestartPolicy
+	// upgrades overrides the upgrade schedule of in-process nodes
+	upgrades *upgrade.Config
+	events   *events.Log
+
+	lock sync.Mutex
+	// cond is signalled when [held] is cleared or the node is stopped
+	cond *sync.Cond
+	// binary is the avalanchego binary the node runs with. The node runs
+	// in-process if it is empty.
+	binary  string
+	app     app.App
+	running bool
+	// held is set while the node is taken down on purpose, so that its exit
+	// isn't treated as a crash
+	held bool
+	// paused is set while the node is held down until [resume] is called
+	paused   bool
+	stopped  bool
+	restarts int
+	crashes  []Crash
+}
+
+func newNodeRunner(nodeNum int, id string, nodeDir string, args []string, binary string, policy RestartPolicy, upgrades *upgrade.Config, log *events.Log) *nodeRunner {
+	r := &nodeRunner{
+		nodeNum:  nodeNum,
+		id:       id,
+		nodeDir:  nodeDir,
+		args:     args,
+		policy:   policy,
+		binary:   binary,
+		upgrades: upgrades,
+		events:   log,
+	}
+	r.cond = sync.NewCond(&r.lock)
+	return r
+}
+
+func (r *nodeRunner) start() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.startLocked()
+}
+
+func (r *nodeRunner) startLocked() error {
+	if r.stopped {
+		return nil
+	}
+	a, err := r.newApp()
+	if err != nil {
+		return fmt.Errorf("%w: node%d: %v", ErrNodeStart, r.nodeNum+1, err)
+	}
+	a.Start()
+	r.app = a
+	r.running = true
+	r.events.Emit(events.Event{
+		Type:    events.NodeStarted,
+		Message: fmt.Sprintf("node%d started", r.nodeNum+1),
+		Node:    r.nodeNum + 1,
+		NodeID:  r.id,
+	})
+	return nil
+}
+
+// emitExit records that the node exited with [exitCode]
+func (r *nodeRunner) emitExit(exitCode int) {
+	r.events.Emit(events.Event{
+		Type:     events.NodeExited,
+		Message:  fmt.Sprintf("node%d exited with code %d", r.nodeNum+1, exitCode),
+		Node:     r.nodeNum + 1,
+		NodeID:   r.id,
+		ExitCode: &exitCode,
+	})
+}
+
+// newApp creates a fresh instance of the node, either in-process or as an
+// avalanchego process
+func (r *nodeRunner) newApp() (app.App, error) {
+	if len(r.binary) > 0 {
+		return newProcessApp(fmt.Sprintf("node%d", r.nodeNum+1), r.binary, r.args, r.nodeDir)
+	}
+	nodeConfig, err := createNodeConfig(r.args, r.upgrades)
+	if err != nil {
+		return nil, err
+	}
+	return app.New(nodeConfig)
+}
+
+// supervise waits for the node to exit and restarts it as long as its policy
+// allows. It returns an error once a node exits and will not be restarted.
+func (r *nodeRunner) supervise(ctx context.Context) error {
+	for {
+		r.lock.Lock()
+		a := r.app
+		r.lock.Unlock()
+
+		exitCode := a.ExitCode()
+
+		r.lock.Lock()
+		for r.held && !r.stopped {
+			r.cond.Wait()
+		}
+		if r.stopped || ctx.Err() != nil {
+			r.running = false
+			r.lock.Unlock()
+			return nil
+		}
+		if r.app != a {
+			// The node was restarted on purpose
+			r.lock.Unlock()
+			continue
+		}
+		r.running = false
+		crash := Crash{
+			Time:     time.Now(),
+			ExitCode: exitCode,
+		}
+		for _, path := range r.crashLogsLocked() {
+			crash.LastLogLines = append(crash.LastLogLines, utils.TailFile(path, crashLogLines)...)
+		}
+		r.crashes = append(r.crashes, crash)
+		restart := r.policy.shouldRestart(exitCode, r.restarts)
+		if restart {
+			r.restarts++
+		}
+		restarts := r.restarts
+		r.lock.Unlock()
+
+		r.emitExit(exitCode)
+		color.Red("node%d exited with code %d, last log lines:", r.nodeNum+1, exitCode)
+		for _, line := range crash.LastLogLines {
+			color.Red("  %s", line)
+		}
+		if !restart {
+			return fmt.Errorf("%w: node%d exited with code %d", ErrNodeCrashed, r.nodeNum+1, exitCode)
+		}
+
+		color.Yellow("restarting node%d in %s (restart %d)", r.nodeNum+1, restartDelay, restarts)
+		select {
+		case <-time.After(restartDelay):
+		case <-ctx.Done():
+			return nil
+		}
+		if err := r.start(); err != nil {
+			return err
+		}
+	}
+}
+
+// crashLogsLocked returns the files whose last lines are recorded on a crash
+func (r *nodeRunner) crashLogsLocked() []string {
+	logs := []string{fmt.Sprintf("%s/logs/main.log", r.nodeDir)}
+	if len(r.binary) > 0 {
+		logs = append(logs, fmt.Sprintf("%s/stderr.log", r.nodeDir))
+	}
+	return logs
+}
+
+// restart stops the node and starts it again, with [binary] if it is set.
+// Planned restarts don't count against the restart policy.
+func (r *nodeRunner) restart(binary string) error {
+	r.lock.Lock()
+	if r.stopped {
+		r.lock.Unlock()
+		return errNetworkStopped
+	}
+	if r.held {
+		r.lock.Unlock()
+		return fmt.Errorf("node%d: %w", r.nodeNum+1, errNodeBusy)
+	}
+	if len(binary) > 0 && r.upgrades != nil {
+		r.lock.Unlock()
+		return fmt.Errorf("node%d: %w", r.nodeNum+1, errUpgradeSchedule)
+	}
+	r.held = true
+	a := r.app
+	r.lock.Unlock()
+
+	color.Yellow("restarting node%d", r.nodeNum+1)
+	a.Stop()
+	r.emitExit(a.ExitCode())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	defer r.cond.Broadcast()
+
+	r.running = false
+	previous := r.binary
+	if len(binary) > 0 {
+		r.binary = binary
+	}
+	if err := r.startLocked(); err != nil {
+		// The node is kept down as if it was stopped, so that its planned
+		// exit isn't taken for a crash, until [resume] starts it again with
+		// its previous binary
+		r.binary = previous
+		r.paused = true
+		r.events.Emit(events.Event{
+			Type:    events.NodeRestartFailed,
+			Message: fmt.Sprintf("node%d failed to restart: %s", r.nodeNum+1, err),
+			Node:    r.nodeNum + 1,
+			NodeID:  r.id,
+		})
+		return err
+	}
+	r.held = false
+	return nil
+}
+
+// pause stops the node and keeps it down until [resume] is called. The exit
+// doesn't count as a crash.
+func (r *nodeRunner) pause() error {
+	r.lock.Lock()
+	if r.stopped {
+		r.lock.Unlock()
+		return errNetworkStopped
+	}
+	if r.held {
+		r.lock.Unlock()
+		return fmt.Errorf("node%d: %w", r.nodeNum+1, errNodeBusy)
+	}
+	r.held = true
+	r.paused = true
+	a := r.app
+	r.lock.Unlock()
+
+	color.Yellow("stopping node%d", r.nodeNum+1)
+	a.Stop()
+	r.emitExit(a.ExitCode())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.running = false
+	return nil
+}
+
+// resume starts a node stopped by [pause] again
+func (r *nodeRunner) resume() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stopped {
+		return errNetworkStopped
+	}
+	if !r.paused {
+		return fmt.Errorf("node%d: %w", r.nodeNum+1, errNodeNotPaused)
+	}
+	defer r.cond.Broadcast()
+
+	color.Yellow("starting node%d", r.nodeNum+1)
+	if err := r.startLocked(); err != nil {
+		// The node stays paused, so that [supervise] keeps waiting and it can
+		// be resumed again
+		r.events.Emit(events.Event{
+			Type:    events.NodeRestartFailed,
+			Message: fmt.Sprintf("node%d failed to start: %s", r.nodeNum+1, err),
+			Node:    r.nodeNum + 1,
+			NodeID:  r.id,
+		})
+		return err
+	}
+	r.paused = false
+	r.held = false
+	return nil
+}
+
+// stop shuts the node down and waits for it to exit. The node is not
+// restarted afterwards.
+func (r *nodeRunner) stop() {
+	r.lock.Lock()
+	r.stopped = true
+	r.cond.Broadcast()
+	a := r.app
+	r.lock.Unlock()
+
+	if a == nil {
+		return
+	}
+	a.Stop()
+	exitCode := a.ExitCode()
+	r.emitExit(exitCode)
+	color.Cyan("node%d exited with code %d", r.nodeNum+1, exitCode)
+}
+
+func (r *nodeRunner) status() NodeStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return NodeStatus{
+		Node:            r.nodeNum + 1,
+		ID:              r.id,
+		Running:         r.running,
+		Stopped:         r.paused,
+		AvalancheGoPath: r.binary,
+		Policy:          r.policy,
+		Restarts:        r.restarts,
+		Crashes:         append([]Crash{}, r.crashes...),
+	}
+}
@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodeDir string
+		binary  string
+		wantErr bool
+	}{
+		{
+			name:    "started",
+			nodeDir: t.TempDir(),
+			binary:  "/bin/true",
+		},
+		{
+			name:    "start fails",
+			nodeDir: filepath.Join(t.TempDir(), "missing"),
+			binary:  "/bin/true",
+			wantErr: true,
+		},
+		{
+			name:    "missing binary",
+			nodeDir: t.TempDir(),
+			binary:  filepath.Join(t.TempDir(), "avalanchego"),
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newNodeRunner(0, "", test.nodeDir, nil, test.binary, RestartPolicy{Mode: RestartNever}, nil, nil)
+			r.held = true
+			r.paused = true
+
+			err := r.resume()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			if !test.wantErr {
+				r.app.ExitCode()
+				if r.held || r.paused {
+					t.Fatalf("got held %t and paused %t, want the node resumed", r.held, r.paused)
+				}
+				return
+			}
+			if !errors.Is(err, ErrNodeStart) || !r.held || !r.paused {
+				t.Fatalf("got error %v, held %t and paused %t, want the node kept paused", err, r.held, r.paused)
+			}
+			// The node can be resumed again
+			if err := r.resume(); errors.Is(err, errNodeNotPaused) {
+				t.Fatalf("got error %v on the second resume", err)
+			}
+		})
+	}
+}
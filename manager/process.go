@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ava-labs/ava-sim/utils"
+
+	"github.com/ava-labs/avalanchego/app"
+	"github.com/fatih/color"
+)
+
+// stopTimeout is how long a node process gets to exit after SIGTERM before
+// it is killed
+const stopTimeout = 30 * time.Second
+
+var _ app.App = (*processApp)(nil)
+
+// processApp runs a node as a separate avalanchego process so that versions
+// other than the one ava-sim is built against can be tested
+type processApp struct {
+	name           string
+	cmd            *exec.Cmd
+	stdout, stderr *os.File
+
+	done     chan struct{}
+	exitCode int
+	stopOnce sync.Once
+}
+
+// newProcessApp prepares [binary] to be run with [args]. Its stdout and stderr
+// are appended to stdout.log and stderr.log in [nodeDir]. A binary that can't
+// be run fails here rather than as an exit of the node, which would be taken
+// for a crash.
+func newProcessApp(name string, binary string, args []string, nodeDir string) (*processApp, error) {
+	if err := utils.CheckExecutable(binary); err != nil {
+		return nil, err
+	}
+	stdout, err := os.OpenFile(fmt.Sprintf("%s/stdout.log", nodeDir), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := os.OpenFile(fmt.Sprintf("%s/stderr.log", nodeDir), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		stdout.Close()
+		return nil, err
+	}
+
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessAttributes(cmd)
+	return &processApp{
+		name:   name,
+		cmd:    cmd,
+		stdout: stdout,
+		stderr: stderr,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+func (p *processApp) Start() {
+	if err := p.cmd.Start(); err != nil {
+		color.Red("%s failed to start %s: %v", p.name, p.cmd.Path, err)
+		p.exit(1)
+		return
+	}
+	go func() {
+		err := p.cmd.Wait()
+		exitCode := p.cmd.ProcessState.ExitCode()
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			color.Red("%s failed while waiting for %s: %v", p.name, p.cmd.Path, err)
+		}
+		if exitCode < 0 {
+			// Killed by a signal
+			exitCode = 1
+		}
+		p.exit(exitCode)
+	}()
+}
+
+func (p *processApp) Stop() {
+	p.stopOnce.Do(func() {
+		if p.cmd.Process == nil {
+			return
+		}
+		_ = p.cmd.Process.Signal(syscall.SIGTERM)
+		go func() {
+			select {
+			case <-p.done:
+			case <-time.After(stopTimeout):
+				color.Red("%s did not exit after %s, killing it", p.name, stopTimeout)
+				_ = p.cmd.Process.Kill()
+			}
+		}()
+	})
+}
+
+func (p *processApp) ExitCode() int {
+	<-p.done
+	return p.exitCode
+}
+
+func (p *processApp) exit(exitCode int) {
+	p.exitCode = exitCode
+	p.stdout.Close()
+	p.stderr.Close()
+	close(p.done)
+}
@@ -0,0 +1,11 @@
+package manager
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessAttributes makes sure node processes don't outlive ava-sim
+func setProcessAttributes(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+}
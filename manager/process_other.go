@@ -0,0 +1,7 @@
+//go:build !linux
+
+package manager
+
+import "os/exec"
+
+func setProcessAttributes(*exec.Cmd) {}
@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/ava-sim/utils"
+)
+
+// RestartMode decides whether a node that exited on its own is started again
+type RestartMode string
+
+const (
+	RestartNever     RestartMode = "never"
+	RestartOnFailure RestartMode = "on-failure"
+	RestartAlways    RestartMode = "always"
+
+	defaultMaxRestarts = 3
+)
+
+// RestartPolicy is the restart behaviour of a single node
+type RestartPolicy struct {
+	Mode RestartMode `json:"mode"`
+	// MaxRestarts caps how many times the node is restarted. 0 means no limit,
+	// which is only allowed for [RestartAlways].
+	MaxRestarts int `json:"maxRestarts"`
+}
+
+// ParseRestartPolicy parses a policy of the form "never", "on-failure[:N]" or
+// "always[:N]"
+func ParseRestartPolicy(s string) (RestartPolicy, error) {
+	modeStr, maxStr, hasMax := strings.Cut(s, ":")
+	policy := RestartPolicy{Mode: RestartMode(modeStr)}
+	switch policy.Mode {
+	case RestartNever:
+		if hasMax {
+			return RestartPolicy{}, fmt.Errorf("restart policy %q does not take a limit", s)
+		}
+		return policy, nil
+	case RestartOnFailure:
+		policy.MaxRestarts = defaultMaxRestarts
+	case RestartAlways:
+	default:
+		return RestartPolicy{}, fmt.Errorf("unknown restart policy %q", s)
+	}
+	if hasMax {
+		max, err := strconv.Atoi(maxStr)
+		if err != nil || max < 0 {
+			return RestartPolicy{}, fmt.Errorf("invalid restart limit in %q", s)
+		}
+		policy.MaxRestarts = max
+	}
+	if policy.Mode == RestartOnFailure && policy.MaxRestarts == 0 {
+		return RestartPolicy{}, fmt.Errorf("restart policy %q needs a positive limit", s)
+	}
+	return policy, nil
+}
+
+// ParseNodeRestartPolicies parses a comma separated list of per node policies
+// of the form "3=always,5=on-failure:2" into [policies], which is indexed by
+// node number - 1
+func ParseNodeRestartPolicies(s string, policies []RestartPolicy) error {
+	assignments, err := utils.ParseNodeAssignments(s)
+	if err != nil {
+		return fmt.Errorf("invalid node restart policies: %w", err)
+	}
+	for nodeNum, policyStr := range assignments {
+		policy, err := ParseRestartPolicy(policyStr)
+		if err != nil {
+			return err
+		}
+		policies[nodeNum] = policy
+	}
+	return nil
+}
+
+// shouldRestart returns whether a node that exited with [exitCode] after
+// having been restarted [restarts] times must be started again
+func (p RestartPolicy) shouldRestart(exitCode int, restarts int) bool {
+	if p.MaxRestarts > 0 && restarts >= p.MaxRestarts {
+		return false
+	}
+	switch p.Mode {
+	case RestartOnFailure:
+		return exitCode != 0
+	case RestartAlways:
+		return true
+	default:
+		return false
+	}
+}
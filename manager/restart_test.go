@@ -0,0 +1,93 @@
+package manager
+
+import "testing"
+
+func TestParseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    RestartPolicy
+		wantErr bool
+	}{
+		{s: "never", want: RestartPolicy{Mode: RestartNever}},
+		{s: "on-failure", want: RestartPolicy{Mode: RestartOnFailure, MaxRestarts: defaultMaxRestarts}},
+		{s: "on-failure:5", want: RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 5}},
+		{s: "always", want: RestartPolicy{Mode: RestartAlways}},
+		{s: "always:2", want: RestartPolicy{Mode: RestartAlways, MaxRestarts: 2}},
+		{s: "always:0", want: RestartPolicy{Mode: RestartAlways}},
+		{s: "never:1", wantErr: true},
+		{s: "on-failure:0", wantErr: true},
+		{s: "on-failure:-1", wantErr: true},
+		{s: "on-failure:x", wantErr: true},
+		{s: "sometimes", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			got, err := ParseRestartPolicy(test.s)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestShouldRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RestartPolicy
+		exitCode int
+		restarts int
+		want     bool
+	}{
+		{
+			name:     "never",
+			policy:   RestartPolicy{Mode: RestartNever},
+			exitCode: 1,
+		},
+		{
+			name:     "on-failure after a failure",
+			policy:   RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 3},
+			exitCode: 1,
+			restarts: 2,
+			want:     true,
+		},
+		{
+			name:   "on-failure after a clean exit",
+			policy: RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 3},
+		},
+		{
+			name:     "on-failure after the limit",
+			policy:   RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 3},
+			exitCode: 1,
+			restarts: 3,
+		},
+		{
+			name:   "always after a clean exit",
+			policy: RestartPolicy{Mode: RestartAlways, MaxRestarts: 1},
+			want:   true,
+		},
+		{
+			name:     "always after the limit",
+			policy:   RestartPolicy{Mode: RestartAlways, MaxRestarts: 1},
+			exitCode: 2,
+			restarts: 1,
+		},
+		{
+			name:     "always without a limit",
+			policy:   RestartPolicy{Mode: RestartAlways},
+			exitCode: 2,
+			restarts: 100,
+			want:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.policy.shouldRestart(test.exitCode, test.restarts); got != test.want {
+				t.Fatalf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/upgrade"
+	"github.com/fatih/color"
+)
+
+// namedUpgrade is the activation time of a network upgrade, referred to by
+// the name of its upgrade config field without the "Time" suffix
+type namedUpgrade struct {
+	name string
+	time *time.Time
+}
+
+// namedUpgrades returns the upgrades of [config] in activation order
+func namedUpgrades(config *upgrade.Config) []namedUpgrade {
+	return []namedUpgrade{
+		{"apricotPhase1", &config.ApricotPhase1Time},
+		{"apricotPhase2", &config.ApricotPhase2Time},
+		{"apricotPhase3", &config.ApricotPhase3Time},
+		{"apricotPhase4", &config.ApricotPhase4Time},
+		{"apricotPhase5", &config.ApricotPhase5Time},
+		{"apricotPhasePre6", &config.ApricotPhasePre6Time},
+		{"apricotPhase6", &config.ApricotPhase6Time},
+		{"apricotPhasePost6", &config.ApricotPhasePost6Time},
+		{"banff", &config.BanffTime},
+		{"cortina", &config.CortinaTime},
+		{"durango", &config.DurangoTime},
+		{"etna", &config.EtnaTime},
+		{"fortuna", &config.FortunaTime},
+		{"granite", &config.GraniteTime},
+	}
+}
+
+// LoadUpgradeConfig reads an avalanchego upgrade file. Upgrades missing from
+// the file keep their default local network activation times.
+func LoadUpgradeConfig(path string) (upgrade.Config, error) {
+	config := upgrade.Default
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return upgrade.Config{}, err
+	}
+	if err := json.Unmarshal(b, &config); err != nil {
+		return upgrade.Config{}, fmt.Errorf("could not parse upgrade file %s: %w", path, err)
+	}
+	return config, config.Validate()
+}
+
+// ParseUpgradeSchedule sets the activation times listed in [schedule] on
+// [config]. [schedule] is a comma separated list of upgrade=time, where time
+// is either a duration after [start] (e.g. granite=+10m) or an RFC3339
+// timestamp. Upgrades that follow a scheduled one and aren't listed are
+// delayed to activate with it, so the schedule stays in order.
+func ParseUpgradeSchedule(schedule string, start time.Time, config *upgrade.Config) error {
+	if len(schedule) == 0 {
+		return nil
+	}
+
+	upgrades := namedUpgrades(config)
+	scheduled := make(map[int]bool)
+	for _, entry := range strings.Split(schedule, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid upgrade %q (expecting upgrade=+duration or upgrade=time)", entry)
+		}
+
+		index := -1
+		for i, u := range upgrades {
+			if strings.EqualFold(u.name, parts[0]) {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return fmt.Errorf("unknown upgrade %q", parts[0])
+		}
+
+		var activation time.Time
+		if strings.HasPrefix(parts[1], "+") {
+			delay, err := time.ParseDuration(parts[1][1:])
+			if err != nil {
+				return fmt.Errorf("invalid delay for %s: %w", parts[0], err)
+			}
+			activation = start.Add(delay)
+		} else {
+			var err error
+			activation, err = time.Parse(time.RFC3339, parts[1])
+			if err != nil {
+				return fmt.Errorf("invalid time for %s: %w", parts[0], err)
+			}
+		}
+		*upgrades[index].time = activation.UTC().Truncate(time.Second)
+		scheduled[index] = true
+	}
+
+	for i := 1; i < len(upgrades); i++ {
+		previous := *upgrades[i-1].time
+		if !scheduled[i] && upgrades[i].time.Before(previous) {
+			*upgrades[i].time = previous
+		}
+	}
+	return config.Validate()
+}
+
+// printUpgradeSchedule lists the upgrades of [config] that activate after
+// genesis
+func printUpgradeSchedule(config upgrade.Config) {
+	for _, u := range namedUpgrades(&config) {
+		if u.time.After(upgrade.InitiallyActiveTime) && !u.time.Equal(upgrade.UnscheduledActivationTime) {
+			color.Yellow("%s activates at %s", u.name, u.time.Format(time.RFC3339))
+		}
+	}
+}
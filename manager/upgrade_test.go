@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/upgrade"
+)
+
+func TestParseUpgradeSchedule(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	durango := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule string
+		// want returns the expected config from the default one
+		want    func(config *upgrade.Config)
+		wantErr bool
+	}{
+		{
+			name:     "empty",
+			schedule: "",
+			want:     func(*upgrade.Config) {},
+		},
+		{
+			name:     "delay",
+			schedule: "granite=+10m",
+			want: func(config *upgrade.Config) {
+				config.GraniteTime = start.Add(10 * time.Minute)
+			},
+		},
+		{
+			name:     "later upgrades are delayed",
+			schedule: "etna=+5m",
+			want: func(config *upgrade.Config) {
+				config.EtnaTime = start.Add(5 * time.Minute)
+				config.FortunaTime = start.Add(5 * time.Minute)
+			},
+		},
+		{
+			name:     "timestamp and case insensitive name",
+			schedule: "Durango=2025-02-01T12:00:00Z, granite=+1000h",
+			want: func(config *upgrade.Config) {
+				config.DurangoTime = durango
+				config.EtnaTime = durango
+				config.FortunaTime = durango
+				config.GraniteTime = start.Add(1000 * time.Hour)
+			},
+		},
+		{
+			name:     "truncated to the second",
+			schedule: "granite=+1500ms",
+			want: func(config *upgrade.Config) {
+				config.GraniteTime = start.Add(time.Second)
+			},
+		},
+		{
+			name:     "out of order",
+			schedule: "fortuna=+10m,etna=+20m",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown upgrade",
+			schedule: "helicon=+10m",
+			wantErr:  true,
+		},
+		{
+			name:     "missing time",
+			schedule: "granite",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid delay",
+			schedule: "granite=+soon",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid time",
+			schedule: "granite=tomorrow",
+			wantErr:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := upgrade.Default
+			err := ParseUpgradeSchedule(test.schedule, start, &config)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.wantErr {
+				return
+			}
+			want := upgrade.Default
+			test.want(&want)
+			if config != want {
+				t.Fatalf("got %+v, want %+v", config, want)
+			}
+		})
+	}
+}
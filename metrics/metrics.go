@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// NodeIDLabel is the label every metric of a node is tagged with
+	NodeIDLabel = "node_id"
+
+	// upMetric reports whether the metrics of a node could be scraped
+	upMetric = "avasim_node_up"
+)
+
+// ContentType is the content type of the metrics written by [Write]
+var ContentType = string(expfmt.NewFormat(expfmt.TypeTextPlain))
+
+// Target is a node whose metrics are scraped
+type Target struct {
+	NodeID string
+	URI    string
+}
+
+// Gather scrapes /ext/metrics on every target at the same time and merges the
+// metrics into one set of families, tagging every metric with the ID of its
+// node. Nodes that can't be scraped, for example because they are stopped, are
+// reported as down by the avasim_node_up metric.
+func Gather(ctx context.Context, targets []Target) []*dto.MetricFamily {
+	scraped := make([]map[string]*dto.MetricFamily, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			families, err := scrape(ctx, target.URI)
+			if err != nil {
+				return
+			}
+			scraped[i] = families
+		}(i, target)
+	}
+	wg.Wait()
+
+	up := &dto.MetricFamily{
+		Name: proto.String(upMetric),
+		Help: proto.String("Whether the metrics of the node could be scraped"),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	merged := map[string]*dto.MetricFamily{upMetric: up}
+	for i, families := range scraped {
+		label := &dto.LabelPair{
+			Name:  proto.String(NodeIDLabel),
+			Value: proto.String(targets[i].NodeID),
+		}
+		value := 0.0
+		if families != nil {
+			value = 1
+		}
+		up.Metric = append(up.Metric, &dto.Metric{
+			Label: []*dto.LabelPair{label},
+			Gauge: &dto.Gauge{Value: proto.Float64(value)},
+		})
+
+		for name, family := range families {
+			m, ok := merged[name]
+			if !ok {
+				m = &dto.MetricFamily{
+					Name: family.Name,
+					Help: family.Help,
+					Type: family.Type,
+				}
+				merged[name] = m
+			}
+			// Nodes running different versions may disagree on the type of
+			// a metric, which a family can't hold
+			if m.GetType() != family.GetType() {
+				continue
+			}
+			for _, metric := range family.Metric {
+				metric.Label = append([]*dto.LabelPair{label}, metric.Label...)
+				m.Metric = append(m.Metric, metric)
+			}
+		}
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(merged))
+	for _, family := range merged {
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+	return families
+}
+
+// Write writes [families] in the Prometheus text format
+func Write(w io.Writer, families []*dto.MetricFamily) error {
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(w, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScrapeConfig returns a Prometheus config scraping the metrics of every node
+// from the ava-sim API at [address]
+func ScrapeConfig(address string) []byte {
+	return []byte(fmt.Sprintf(`global:
+  scrape_interval: 10s
+  scrape_timeout: 10s
+
+scrape_configs:
+  - job_name: ava-sim
+    metrics_path: /metrics
+    static_configs:
+      - targets: ["%s"]
+`, address))
+}
+
+func scrape(ctx context.Context, nodeURI string) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nodeURI+"/ext/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
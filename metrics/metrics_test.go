@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// metricsServer serves [body] on /ext/metrics, or fails if it is empty
+func metricsServer(t *testing.T, body string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ext/metrics" || len(body) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestGather(t *testing.T) {
+	tests := []struct {
+		name string
+		// bodies holds the metrics served by every node, empty for the nodes
+		// that can't be scraped
+		bodies []string
+		want   string
+	}{
+		{
+			name: "merged families",
+			bodies: []string{
+				"# HELP a_total A.\n# TYPE a_total counter\na_total{chain=\"C\"} 1\n",
+				"# HELP a_total A.\n# TYPE a_total counter\na_total{chain=\"C\"} 2\n# TYPE b gauge\nb 3\n",
+			},
+			want: `# HELP a_total A.
+# TYPE a_total counter
+a_total{node_id="node1",chain="C"} 1
+a_total{node_id="node2",chain="C"} 2
+# HELP avasim_node_up Whether the metrics of the node could be scraped
+# TYPE avasim_node_up gauge
+avasim_node_up{node_id="node1"} 1
+avasim_node_up{node_id="node2"} 1
+# TYPE b gauge
+b{node_id="node2"} 3
+`,
+		},
+		{
+			name: "down node",
+			bodies: []string{
+				"",
+				"# TYPE b gauge\nb 3\n",
+			},
+			want: `# HELP avasim_node_up Whether the metrics of the node could be scraped
+# TYPE avasim_node_up gauge
+avasim_node_up{node_id="node1"} 0
+avasim_node_up{node_id="node2"} 1
+# TYPE b gauge
+b{node_id="node2"} 3
+`,
+		},
+		{
+			name: "conflicting types",
+			bodies: []string{
+				"# TYPE b gauge\nb 3\n",
+				"# TYPE b counter\nb 4\n",
+			},
+			want: `# HELP avasim_node_up Whether the metrics of the node could be scraped
+# TYPE avasim_node_up gauge
+avasim_node_up{node_id="node1"} 1
+avasim_node_up{node_id="node2"} 1
+# TYPE b gauge
+b{node_id="node1"} 3
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targets := make([]Target, len(test.bodies))
+			for i, body := range test.bodies {
+				targets[i] = Target{
+					NodeID: fmt.Sprintf("node%d", i+1),
+					URI:    metricsServer(t, body),
+				}
+			}
+			var got bytes.Buffer
+			if err := Write(&got, Gather(context.Background(), targets)); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != test.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got.String(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/events"
+)
+
+// Types of the events recorded by the monitor
+const (
+	EventMonitor         = "monitor"
+	EventSafetyViolation = "safety-violation"
+	EventLivenessStall   = "liveness-stall"
+	EventLivenessResumed = "liveness-resumed"
+	EventNodeLagging     = "node-lagging"
+	EventNodeCaughtUp    = "node-caught-up"
+)
+
+var (
+	// ErrSafetyViolation is reported when nodes accepted different blocks at
+	// the same height
+	ErrSafetyViolation = errors.New("safety violation")
+	// ErrLivenessStall is reported when a chain idle for longer than
+	// [Config.StallTimeout] did not accept a probe tx within as long
+	ErrLivenessStall = errors.New("liveness stall")
+)
+
+// Config configures the monitor
+type Config struct {
+	// Interval is how often every node is queried
+	Interval time.Duration
+	// StallTimeout is how long a chain may go without accepting a block
+	// before it is probed, and then how long the probe may take to be
+	// accepted, or how long a node may stay behind the others, before it is
+	// reported
+	StallTimeout time.Duration
+	// Probe issues a tx on [chain] through the node at [issuerURI] and
+	// returns once every node in [nodeURIs] accepted it. An idle chain builds
+	// no blocks, so that a chain is only reported as stalled if it doesn't
+	// accept a probe.
+	Probe func(ctx context.Context, issuerURI string, nodeURIs []string, chain string) error
+}
+
+// Chain is a chain the monitor watches
+type Chain struct {
+	// Name is the alias or ID of the chain
+	Name string
+	// EVM reads the chain over JSON-RPC instead of the index API, which only
+	// serves the primary network chains
+	EVM bool
+}
+
+// Report sums up what the monitor found so far
+type Report struct {
+	Chains           []ChainStatus `json:"chains"`
+	SafetyViolations []string      `json:"safetyViolations"`
+	LivenessStalls   []string      `json:"livenessStalls"`
+}
+
+// ChainStatus is the last state of a chain seen by the monitor
+type ChainStatus struct {
+	Chain string `json:"chain"`
+	// Heights holds the height of the last block accepted by every node, or
+	// nil for the nodes that didn't respond
+	Heights      []*uint64 `json:"heights"`
+	LastAdvanced time.Time `json:"lastAdvanced"`
+	Stalled      bool      `json:"stalled"`
+}
+
+// Monitor checks that the nodes agree on the blocks they accept (safety) and
+// keep accepting new ones (liveness)
+type Monitor struct {
+	config   Config
+	log      *events.Log
+	nodeURIs []string
+	// probes tracks the probes in flight, which outlive the round that
+	// started them
+	probes sync.WaitGroup
+
+	lock             sync.Mutex
+	chains           []*chainState
+	safetyViolations []string
+	livenessStalls   []string
+}
+
+// chainState tracks a chain across rounds
+type chainState struct {
+	name    string
+	readers []chainReader
+
+	heights      []*uint64
+	maxHeight    uint64
+	lastAdvanced time.Time
+	probing      bool
+	stalled      bool
+	// behindSince holds when every node fell behind the others, or zero if it
+	// is up to date
+	behindSince []time.Time
+	lagging     []bool
+	// violations holds the heights a violation was reported at
+	violations map[uint64]bool
+}
+
+// New creates a monitor of [chains] on the nodes at [nodeURIs]
+func New(config Config, nodeURIs []string, chains []Chain, log *events.Log) (*Monitor, error) {
+	m := &Monitor{
+		config:   config,
+		log:      log,
+		nodeURIs: nodeURIs,
+	}
+	for _, chain := range chains {
+		state := &chainState{
+			name:         chain.Name,
+			heights:      make([]*uint64, len(nodeURIs)),
+			lastAdvanced: time.Now(),
+			behindSince:  make([]time.Time, len(nodeURIs)),
+			lagging:      make([]bool, len(nodeURIs)),
+			violations:   make(map[uint64]bool),
+		}
+		for _, uri := range nodeURIs {
+			if !chain.EVM {
+				state.readers = append(state.readers, newIndexReader(uri, chain.Name))
+				continue
+			}
+			reader, err := newEVMReader(uri, chain.Name)
+			if err != nil {
+				return nil, fmt.Errorf("could not connect to %s: %w", chain.Name, err)
+			}
+			state.readers = append(state.readers, reader)
+		}
+		m.chains = append(m.chains, state)
+	}
+	return m, nil
+}
+
+// Run checks the chains every [Config.Interval] until [ctx] is cancelled
+func (m *Monitor) Run(ctx context.Context) error {
+	defer func() {
+		m.probes.Wait()
+		for _, chain := range m.chains {
+			for _, reader := range chain.readers {
+				reader.close()
+			}
+		}
+	}()
+
+	names := make([]string, len(m.chains))
+	for i, chain := range m.chains {
+		names[i] = chain.name
+	}
+	m.log.Record(EventMonitor, "monitoring %s every %s", strings.Join(names, ", "), m.config.Interval)
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+		for _, chain := range m.chains {
+			m.check(ctx, chain)
+		}
+	}
+}
+
+// check runs one round of checks on [chain]. Nodes that don't respond, for
+// example because they are stopped, are left out of the round. A probe of an
+// idle chain runs until it is accepted or [ctx] is cancelled.
+func (m *Monitor) check(ctx context.Context, chain *chainState) {
+	roundCtx, cancel := context.WithTimeout(ctx, m.config.Interval)
+	defer cancel()
+
+	var (
+		heights     = make([]*uint64, len(chain.readers))
+		minHeight   *uint64
+		maxHeight   uint64
+		firstHeight uint64
+		firstID     string
+		agreed      = true
+	)
+	for i, reader := range chain.readers {
+		height, id, err := reader.lastAccepted(roundCtx)
+		if err != nil {
+			continue
+		}
+		if minHeight == nil {
+			firstHeight, firstID = height, id
+		} else if height != firstHeight || id != firstID {
+			agreed = false
+		}
+		heights[i] = &height
+		if minHeight == nil || height < *minHeight {
+			minHeight = &height
+		}
+		if height > maxHeight {
+			maxHeight = height
+		}
+	}
+	if roundCtx.Err() != nil || minHeight == nil {
+		return
+	}
+
+	// Nodes at different heights are compared at the highest height they all
+	// reached
+	if !agreed {
+		m.checkSafety(roundCtx, chain, heights, *minHeight)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	chain.heights = heights
+	if maxHeight > chain.maxHeight {
+		chain.maxHeight = maxHeight
+		chain.lastAdvanced = now
+		if chain.stalled {
+			chain.stalled = false
+			m.log.Record(EventLivenessResumed, "%s-chain is accepting blocks again at height %d", chain.name, maxHeight)
+		}
+	} else if !chain.probing && now.Sub(chain.lastAdvanced) > m.config.StallTimeout {
+		// The probe is issued and waited for through the nodes that responded
+		var nodeURIs []string
+		for i, height := range heights {
+			if height != nil {
+				nodeURIs = append(nodeURIs, m.nodeURIs[i])
+			}
+		}
+		chain.probing = true
+		m.probes.Add(1)
+		go m.probe(ctx, chain, nodeURIs)
+	}
+
+	for i, height := range heights {
+		if height == nil {
+			continue
+		}
+		if *height >= chain.maxHeight {
+			if chain.lagging[i] {
+				m.log.Record(EventNodeCaughtUp, "node%d caught up on %s-chain at height %d", i+1, chain.name, *height)
+			}
+			chain.behindSince[i] = time.Time{}
+			chain.lagging[i] = false
+			continue
+		}
+		if chain.behindSince[i].IsZero() {
+			chain.behindSince[i] = now
+		}
+		if !chain.lagging[i] && now.Sub(chain.behindSince[i]) > m.config.StallTimeout {
+			chain.lagging[i] = true
+			m.log.Record(EventNodeLagging, "node%d is behind on %s-chain at height %d while others reached %d",
+				i+1, chain.name, *height, chain.maxHeight)
+		}
+	}
+}
+
+// probe issues a tx on the idle [chain] and reports it as stalled if the tx
+// isn't accepted within [Config.StallTimeout]
+func (m *Monitor) probe(ctx context.Context, chain *chainState, nodeURIs []string) {
+	defer m.probes.Done()
+
+	probeCtx, cancel := context.WithTimeout(ctx, m.config.StallTimeout)
+	defer cancel()
+	err := m.config.Probe(probeCtx, nodeURIs[0], nodeURIs, chain.name)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	chain.probing = false
+	switch {
+	case ctx.Err() != nil:
+	case err == nil:
+		chain.lastAdvanced = time.Now()
+		if chain.stalled {
+			chain.stalled = false
+			m.log.Record(EventLivenessResumed, "%s-chain is accepting blocks again", chain.name)
+		}
+	case !errors.Is(err, context.DeadlineExceeded):
+		// The probe failed for reasons other than the chain, such as its
+		// issuer being stopped
+		m.log.Record(EventMonitor, "could not probe %s-chain: %s", chain.name, err)
+	case !chain.stalled:
+		chain.stalled = true
+		stall := fmt.Sprintf("%s-chain did not accept a probe tx within %s, and has not accepted a block since %s (height %d)",
+			chain.name, m.config.StallTimeout, chain.lastAdvanced.UTC().Format(time.RFC3339), chain.maxHeight)
+		m.livenessStalls = append(m.livenessStalls, stall)
+		m.log.Record(EventLivenessStall, "%s", stall)
+	}
+}
+
+// checkSafety compares the blocks the responding nodes accepted at [height]
+func (m *Monitor) checkSafety(ctx context.Context, chain *chainState, heights []*uint64, height uint64) {
+	var (
+		firstNode = -1
+		firstID   string
+	)
+	for i, reader := range chain.readers {
+		if heights[i] == nil {
+			continue
+		}
+		id, err := reader.acceptedAt(ctx, height)
+		if err != nil {
+			continue
+		}
+		if firstNode == -1 {
+			firstNode, firstID = i, id
+			continue
+		}
+		if id == firstID {
+			continue
+		}
+
+		m.lock.Lock()
+		if !chain.violations[height] {
+			chain.violations[height] = true
+			violation := fmt.Sprintf("%s-chain: node%d accepted %s but node%d accepted %s at height %d",
+				chain.name, firstNode+1, firstID, i+1, id, height)
+			m.safetyViolations = append(m.safetyViolations, violation)
+			m.log.Record(EventSafetyViolation, "%s", violation)
+		}
+		m.lock.Unlock()
+		return
+	}
+}
+
+// Report returns what the monitor found so far
+func (m *Monitor) Report() Report {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	report := Report{
+		SafetyViolations: append([]string{}, m.safetyViolations...),
+		LivenessStalls:   append([]string{}, m.livenessStalls...),
+	}
+	for _, chain := range m.chains {
+		report.Chains = append(report.Chains, ChainStatus{
+			Chain:        chain.name,
+			Heights:      chain.heights,
+			LastAdvanced: chain.lastAdvanced,
+			Stalled:      chain.stalled,
+		})
+	}
+	return report
+}
+
+// Err returns [ErrSafetyViolation] or [ErrLivenessStall] if the monitor found
+// one, the former taking precedence
+func (m *Monitor) Err() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	switch {
+	case len(m.safetyViolations) > 0:
+		return fmt.Errorf("%w: %s", ErrSafetyViolation, m.safetyViolations[0])
+	case len(m.livenessStalls) > 0:
+		return fmt.Errorf("%w: %s", ErrLivenessStall, m.livenessStalls[0])
+	default:
+		return nil
+	}
+}
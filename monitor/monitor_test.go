@@ -0,0 +1,200 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeReader serves [blocks], which maps heights to block IDs, up to [height]
+type fakeReader struct {
+	height uint64
+	blocks map[uint64]string
+	// down makes the node fail to respond
+	down bool
+}
+
+func (r fakeReader) lastAccepted(context.Context) (uint64, string, error) {
+	if r.down {
+		return 0, "", errors.New("node is down")
+	}
+	return r.height, r.blocks[r.height], nil
+}
+
+func (r fakeReader) acceptedAt(_ context.Context, height uint64) (string, error) {
+	if r.down || height > r.height {
+		return "", errors.New("block not found")
+	}
+	return r.blocks[height], nil
+}
+
+func (fakeReader) close() {}
+
+func TestCheck(t *testing.T) {
+	var (
+		chain  = map[uint64]string{1: "a1", 2: "a2", 3: "a3"}
+		forked = map[uint64]string{1: "a1", 2: "b2", 3: "b3"}
+	)
+	tests := []struct {
+		name    string
+		readers []fakeReader
+		// maxHeight is the highest height seen by previous rounds, and
+		// sinceAdvanced how long ago it was reached
+		maxHeight     uint64
+		sinceAdvanced time.Duration
+		// probeErr is returned by the probes of idle chains
+		probeErr error
+		wantErr  error
+	}{
+		{
+			name: "nodes agree",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: chain},
+			},
+		},
+		{
+			name: "node behind agrees",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 2, blocks: chain},
+			},
+		},
+		{
+			name: "different blocks at the same height",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: forked},
+			},
+			wantErr: ErrSafetyViolation,
+		},
+		{
+			name: "node behind disagrees",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 2, blocks: forked},
+			},
+			wantErr: ErrSafetyViolation,
+		},
+		{
+			name: "down node is left out",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: forked, down: true},
+				{height: 3, blocks: chain},
+			},
+		},
+		{
+			name: "chain advanced",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: chain},
+			},
+			maxHeight:     2,
+			sinceAdvanced: time.Hour,
+		},
+		{
+			name: "idle chain accepts probe",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: chain},
+			},
+			maxHeight:     3,
+			sinceAdvanced: time.Hour,
+		},
+		{
+			name: "chain stalled",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: chain},
+			},
+			maxHeight:     3,
+			sinceAdvanced: time.Hour,
+			probeErr:      fmt.Errorf("probe not accepted: %w", context.DeadlineExceeded),
+			wantErr:       ErrLivenessStall,
+		},
+		{
+			name: "probe not issued",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: chain},
+			},
+			maxHeight:     3,
+			sinceAdvanced: time.Hour,
+			probeErr:      errors.New("could not create wallet"),
+		},
+		{
+			name: "chain not stalled yet",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: chain},
+			},
+			maxHeight:     3,
+			sinceAdvanced: time.Second,
+		},
+		{
+			name: "all nodes down",
+			readers: []fakeReader{
+				{down: true},
+				{down: true},
+			},
+			maxHeight:     3,
+			sinceAdvanced: time.Hour,
+		},
+		{
+			name: "safety violation takes precedence",
+			readers: []fakeReader{
+				{height: 3, blocks: chain},
+				{height: 3, blocks: forked},
+			},
+			maxHeight:     3,
+			sinceAdvanced: time.Hour,
+			probeErr:      context.DeadlineExceeded,
+			wantErr:       ErrSafetyViolation,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := &Monitor{
+				config: Config{
+					Interval:     time.Second,
+					StallTimeout: time.Minute,
+					Probe: func(context.Context, string, []string, string) error {
+						return test.probeErr
+					},
+				},
+				nodeURIs: make([]string, len(test.readers)),
+			}
+			state := &chainState{
+				name:         "C",
+				heights:      make([]*uint64, len(test.readers)),
+				maxHeight:    test.maxHeight,
+				lastAdvanced: time.Now().Add(-test.sinceAdvanced),
+				behindSince:  make([]time.Time, len(test.readers)),
+				lagging:      make([]bool, len(test.readers)),
+				violations:   make(map[uint64]bool),
+			}
+			for _, reader := range test.readers {
+				state.readers = append(state.readers, reader)
+			}
+			m.chains = []*chainState{state}
+
+			// A finding is only reported once
+			for i := 0; i < 2; i++ {
+				m.check(context.Background(), state)
+				m.probes.Wait()
+			}
+
+			err := m.Err()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got error %v, want %v", err, test.wantErr)
+			}
+			report := m.Report()
+			if len(report.SafetyViolations) > 1 || len(report.LivenessStalls) > 1 {
+				t.Fatalf("findings reported more than once: %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/ava-labs/avalanchego/indexer"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/ethclient"
+)
+
+// chainReader reads the blocks a node accepted on a chain
+type chainReader interface {
+	// lastAccepted returns the height and ID of the last accepted block
+	lastAccepted(ctx context.Context) (uint64, string, error)
+	// acceptedAt returns the ID of the block accepted at [height]
+	acceptedAt(ctx context.Context, height uint64) (string, error)
+	close()
+}
+
+// indexReader reads the blocks of a primary network chain from the index API.
+// Heights are positions in the index.
+type indexReader struct {
+	client *indexer.Client
+}
+
+func newIndexReader(nodeURI string, chain string) *indexReader {
+	return &indexReader{
+		client: indexer.NewClient(fmt.Sprintf("%s/ext/index/%s/block", nodeURI, chain)),
+	}
+}
+
+func (r *indexReader) lastAccepted(ctx context.Context) (uint64, string, error) {
+	container, index, err := r.client.GetLastAccepted(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	return index, container.ID.String(), nil
+}
+
+func (r *indexReader) acceptedAt(ctx context.Context, height uint64) (string, error) {
+	container, err := r.client.GetContainerByIndex(ctx, height)
+	if err != nil {
+		return "", err
+	}
+	return container.ID.String(), nil
+}
+
+func (*indexReader) close() {}
+
+// evmReader reads the blocks of an EVM chain, which isn't indexed, over
+// JSON-RPC. Block hashes are taken as reported by the node, since headers of
+// Avalanche EVMs carry fields the hash computed by libevm would miss.
+type evmReader struct {
+	client *ethclient.Client
+}
+
+// rpcBlock holds the fields of eth_getBlockByNumber the monitor needs
+type rpcBlock struct {
+	Number *hexutil.Big `json:"number"`
+	Hash   common.Hash  `json:"hash"`
+}
+
+func newEVMReader(nodeURI string, chain string) (*evmReader, error) {
+	client, err := ethclient.Dial(evm.RPCURL(nodeURI, chain))
+	if err != nil {
+		return nil, err
+	}
+	return &evmReader{client: client}, nil
+}
+
+func (r *evmReader) block(ctx context.Context, number string) (rpcBlock, error) {
+	var block *rpcBlock
+	if err := r.client.Client().CallContext(ctx, &block, "eth_getBlockByNumber", number, false); err != nil {
+		return rpcBlock{}, err
+	}
+	if block == nil || block.Number == nil {
+		return rpcBlock{}, fmt.Errorf("block %s not found", number)
+	}
+	return *block, nil
+}
+
+func (r *evmReader) lastAccepted(ctx context.Context) (uint64, string, error) {
+	block, err := r.block(ctx, "latest")
+	if err != nil {
+		return 0, "", err
+	}
+	return block.Number.ToInt().Uint64(), block.Hash.Hex(), nil
+}
+
+func (r *evmReader) acceptedAt(ctx context.Context, height uint64) (string, error) {
+	block, err := r.block(ctx, hexutil.EncodeUint64(height))
+	if err != nil {
+		return "", err
+	}
+	return block.Hash.Hex(), nil
+}
+
+func (r *evmReader) close() {
+	r.client.Close()
+}
+
+// Height returns the height of the last block the node at [nodeURI] accepted on
+// [chain]
+func Height(ctx context.Context, nodeURI string, chain Chain) (uint64, error) {
+	var reader chainReader = newIndexReader(nodeURI, chain.Name)
+	if chain.EVM {
+		evmReader, err := newEVMReader(nodeURI, chain.Name)
+		if err != nil {
+			return 0, err
+		}
+		reader = evmReader
+	}
+	defer reader.close()
+
+	height, _, err := reader.lastAccepted(ctx)
+	return height, err
+}
@@ -0,0 +1,291 @@
+package proxy
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/fatih/color"
+	"golang.org/x/time/rate"
+)
+
+// messageQueueSize is how many messages a link holds back before it stops
+// reading from the sender
+const messageQueueSize = 256
+
+// Duration is a time.Duration written as a string such as "100ms" in JSON
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LinkConfig shapes the messages a node sends to another node
+type LinkConfig struct {
+	Latency Duration `json:"latency,omitempty"`
+	// Jitter delays every message by up to this much more or less than
+	// [Latency]. Messages are never reordered.
+	Jitter Duration `json:"jitter,omitempty"`
+	// Bandwidth is the maximum number of bytes per second sent over the link,
+	// or 0 for no limit
+	Bandwidth int `json:"bandwidth,omitempty"`
+	// DropRate is the probability for every message to be dropped
+	DropRate float64 `json:"dropRate,omitempty"`
+	// Blackhole drops all messages while keeping the connection open
+	Blackhole bool `json:"blackhole,omitempty"`
+	// ResetInterval closes the connections going through the link once they
+	// have been open this long
+	ResetInterval Duration `json:"resetInterval,omitempty"`
+}
+
+// Link is the config of the messages sent from node [From] to node [To]
+type Link struct {
+	From   int        `json:"from"`
+	To     int        `json:"to"`
+	Config LinkConfig `json:"config"`
+}
+
+// link applies its config to all connections going from one node to another
+type link struct {
+	lock    sync.RWMutex
+	config  LinkConfig
+	limiter *rate.Limiter
+}
+
+func newLink() *link {
+	return &link{limiter: rate.NewLimiter(rate.Inf, 0)}
+}
+
+func (l *link) getConfig() LinkConfig {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.config
+}
+
+func (l *link) setConfig(config LinkConfig) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.config = config
+	if config.Bandwidth > 0 {
+		// Up to a second worth of bytes can be sent at once
+		l.limiter.SetLimit(rate.Limit(config.Bandwidth))
+		l.limiter.SetBurst(config.Bandwidth)
+	} else {
+		l.limiter.SetLimit(rate.Inf)
+	}
+}
+
+// drop returns whether the message read now is dropped
+func (c LinkConfig) drop() bool {
+	return c.Blackhole || (c.DropRate > 0 && rand.Float64() < c.DropRate)
+}
+
+// delay returns how long the message read now is held back
+func (c LinkConfig) delay() time.Duration {
+	delay := time.Duration(c.Latency)
+	if c.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(2*int64(c.Jitter))) - time.Duration(c.Jitter)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// message is a peer-to-peer message with its length prefix
+type message struct {
+	bytes     []byte
+	deliverAt time.Time
+}
+
+// readMessage reads a length prefixed peer-to-peer message from [r]
+func readMessage(r io.Reader) ([]byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	msgLen := binary.BigEndian.Uint32(prefix[:])
+	if msgLen > constants.DefaultMaxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds the maximum message size", msgLen)
+	}
+	msg := make([]byte, len(prefix)+int(msgLen))
+	copy(msg, prefix[:])
+	if _, err := io.ReadFull(r, msg[len(prefix):]); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// forward copies the messages read from [r] to [w] as shaped by [l] until
+// either side fails or [ctx] is cancelled
+func (l *link) forward(ctx context.Context, r io.Reader, w io.Writer) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queue := make(chan message, messageQueueSize)
+	go func() {
+		defer close(queue)
+		var lastDelivery time.Time
+		for {
+			msg, err := readMessage(r)
+			if err != nil {
+				return
+			}
+			config := l.getConfig()
+			if config.drop() {
+				continue
+			}
+			deliverAt := time.Now().Add(config.delay())
+			if deliverAt.Before(lastDelivery) {
+				deliverAt = lastDelivery
+			}
+			lastDelivery = deliverAt
+			select {
+			case queue <- message{bytes: msg, deliverAt: deliverAt}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for msg := range queue {
+		select {
+		case <-time.After(time.Until(msg.deliverAt)):
+		case <-ctx.Done():
+			return
+		}
+		if err := l.waitBandwidth(ctx, len(msg.bytes)); err != nil {
+			return
+		}
+		if _, err := w.Write(msg.bytes); err != nil {
+			return
+		}
+	}
+}
+
+// waitBandwidth waits until [n] bytes can be sent over the link
+func (l *link) waitBandwidth(ctx context.Context, n int) error {
+	for n > 0 {
+		if l.limiter.Limit() == rate.Inf {
+			return nil
+		}
+		chunk := n
+		if burst := l.limiter.Burst(); chunk > burst {
+			chunk = burst
+		}
+		if err := l.limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// link returns the link messages from node [src] to node [dst] go through
+func (n *Network) link(src, dst int) *link {
+	return n.links[linkKey{src, dst}]
+}
+
+type linkKey struct {
+	from, to int
+}
+
+// SetLinks applies [config] to the links from every node in [from] to every
+// node in [to], and back too unless [oneWay] is set. Empty lists stand for
+// all nodes.
+func (n *Network) SetLinks(from, to []int, oneWay bool, config LinkConfig) error {
+	from, err := n.nodeList(from)
+	if err != nil {
+		return err
+	}
+	to, err = n.nodeList(to)
+	if err != nil {
+		return err
+	}
+	if config.Latency < 0 || config.Jitter < 0 || config.Bandwidth < 0 ||
+		config.DropRate < 0 || config.DropRate > 1 || config.ResetInterval < 0 {
+		return fmt.Errorf("invalid link config %+v", config)
+	}
+
+	for _, src := range from {
+		for _, dst := range to {
+			if src == dst {
+				continue
+			}
+			n.link(src, dst).setConfig(config)
+			if !oneWay {
+				n.link(dst, src).setConfig(config)
+			}
+		}
+	}
+	color.Yellow("links from %v to %v set to %+v", from, to, config)
+	return nil
+}
+
+// ResetLinks removes the config of every link
+func (n *Network) ResetLinks() {
+	for _, l := range n.links {
+		l.setConfig(LinkConfig{})
+	}
+	color.Yellow("links reset")
+}
+
+// Links returns the links that have a config
+func (n *Network) Links() []Link {
+	var links []Link
+	for key, l := range n.links {
+		if config := l.getConfig(); config != (LinkConfig{}) {
+			links = append(links, Link{From: key.from, To: key.to, Config: config})
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].From != links[j].From {
+			return links[i].From < links[j].From
+		}
+		return links[i].To < links[j].To
+	})
+	return links
+}
+
+// nodeList validates [nodes], returning all nodes if it is empty
+func (n *Network) nodeList(nodes []int) ([]int, error) {
+	if len(nodes) == 0 {
+		nodes = make([]int, len(n.nodes))
+		for i := range nodes {
+			nodes[i] = i + 1
+		}
+		return nodes, nil
+	}
+	for _, nodeNum := range nodes {
+		if nodeNum < 1 || nodeNum > len(n.nodes) {
+			return nil, fmt.Errorf("unknown node%d", nodeNum)
+		}
+	}
+	return nodes, nil
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/utils/constants"
+)
+
+func TestLinkConfigDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   LinkConfig
+		min, max time.Duration
+	}{
+		{
+			name: "no latency",
+		},
+		{
+			name:   "latency",
+			config: LinkConfig{Latency: Duration(100 * time.Millisecond)},
+			min:    100 * time.Millisecond,
+			max:    100 * time.Millisecond,
+		},
+		{
+			name: "jitter",
+			config: LinkConfig{
+				Latency: Duration(100 * time.Millisecond),
+				Jitter:  Duration(20 * time.Millisecond),
+			},
+			min: 80 * time.Millisecond,
+			max: 120 * time.Millisecond,
+		},
+		{
+			name: "jitter above latency",
+			config: LinkConfig{
+				Latency: Duration(10 * time.Millisecond),
+				Jitter:  Duration(50 * time.Millisecond),
+			},
+			max: 60 * time.Millisecond,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				if delay := test.config.delay(); delay < test.min || delay > test.max {
+					t.Fatalf("delay %s is not within [%s, %s]", delay, test.min, test.max)
+				}
+			}
+		})
+	}
+}
+
+// prefixed returns [payload] with its length prefix
+func prefixed(length uint32, payload []byte) []byte {
+	msg := binary.BigEndian.AppendUint32(nil, length)
+	return append(msg, payload...)
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		want    []byte
+		wantErr bool
+		// errIs is the error expected to be wrapped, if any
+		errIs error
+	}{
+		{
+			name:  "message",
+			input: prefixed(3, []byte{1, 2, 3}),
+			want:  prefixed(3, []byte{1, 2, 3}),
+		},
+		{
+			name:  "empty message",
+			input: prefixed(0, nil),
+			want:  prefixed(0, nil),
+		},
+		{
+			name:  "followed by another message",
+			input: append(prefixed(1, []byte{1}), prefixed(1, []byte{2})...),
+			want:  prefixed(1, []byte{1}),
+		},
+		{
+			name:    "no message",
+			wantErr: true,
+			errIs:   io.EOF,
+		},
+		{
+			name:    "truncated prefix",
+			input:   []byte{0, 0},
+			wantErr: true,
+			errIs:   io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "truncated message",
+			input:   prefixed(3, []byte{1}),
+			wantErr: true,
+			errIs:   io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "too large",
+			input:   prefixed(constants.DefaultMaxMessageSize+1, nil),
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := readMessage(bytes.NewReader(test.input))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.errIs != nil && !errors.Is(err, test.errIs) {
+				t.Fatalf("got error %v, want %v", err, test.errIs)
+			}
+			if !bytes.Equal(got, test.want) {
+				t.Fatalf("got %x, want %x", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Partition splits the network into groups of nodes that can only reach the
+// nodes of their own group
+type Partition struct {
+	// Groups lists the nodes of every group. Nodes missing from all groups
+	// form one more group.
+	Groups [][]int `json:"groups"`
+	// HealAt is when the partition is healed automatically, if ever
+	HealAt *time.Time `json:"healAt,omitempty"`
+
+	// group maps every listed node to the index of its group
+	group map[int]int
+}
+
+func (p *Partition) connected(src, dst int) bool {
+	srcGroup, ok := p.group[src]
+	if !ok {
+		srcGroup = -1
+	}
+	dstGroup, ok := p.group[dst]
+	if !ok {
+		dstGroup = -1
+	}
+	return srcGroup == dstGroup
+}
+
+// Partition splits the network into [groups], closing the connections between
+// nodes of different groups and refusing new ones. The partition replaces any
+// previous one and is healed after [duration] unless it is 0.
+func (n *Network) Partition(groups [][]int, duration time.Duration) error {
+	p := &Partition{
+		Groups: groups,
+		group:  make(map[int]int),
+	}
+	for i, nodes := range groups {
+		for _, nodeNum := range nodes {
+			if nodeNum < 1 || nodeNum > len(n.nodes) {
+				return fmt.Errorf("unknown node%d", nodeNum)
+			}
+			if _, ok := p.group[nodeNum]; ok {
+				return fmt.Errorf("node%d is in more than one group", nodeNum)
+			}
+			p.group[nodeNum] = i
+		}
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.healTimer != nil {
+		n.healTimer.Stop()
+		n.healTimer = nil
+	}
+	if duration > 0 {
+		healAt := time.Now().Add(duration)
+		p.HealAt = &healAt
+		n.healTimer = time.AfterFunc(duration, func() {
+			n.heal(p)
+		})
+	}
+	n.partition = p
+	color.Yellow("network partitioned into %v", groups)
+	n.closeBlockedLocked()
+	return nil
+}
+
+// Heal removes the partition, if any. Nodes reconnect on their own once their
+// reconnect delay expires.
+func (n *Network) Heal() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.healTimer != nil {
+		n.healTimer.Stop()
+		n.healTimer = nil
+	}
+	if n.partition != nil {
+		n.partition = nil
+		color.Yellow("network partition healed")
+	}
+}
+
+// heal removes [p] if it is still the current partition
+func (n *Network) heal(p *Partition) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.partition == p {
+		n.partition = nil
+		n.healTimer = nil
+		color.Yellow("network partition healed")
+	}
+}
+
+// CurrentPartition returns the partition in place, or nil if the network is
+// whole
+func (n *Network) CurrentPartition() *Partition {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.partition
+}
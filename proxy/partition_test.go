@@ -0,0 +1,29 @@
+package proxy
+
+import "testing"
+
+func TestPartitionConnected(t *testing.T) {
+	// Nodes 4 and 5 are missing from the groups and form one more group
+	p := &Partition{
+		Groups: [][]int{{1, 2}, {3}},
+		group:  map[int]int{1: 0, 2: 0, 3: 1},
+	}
+	tests := []struct {
+		src, dst int
+		want     bool
+	}{
+		{src: 1, dst: 2, want: true},
+		{src: 2, dst: 1, want: true},
+		{src: 1, dst: 3},
+		{src: 3, dst: 2},
+		{src: 3, dst: 3, want: true},
+		{src: 4, dst: 5, want: true},
+		{src: 4, dst: 1},
+		{src: 3, dst: 5},
+	}
+	for _, test := range tests {
+		if got := p.connected(test.src, test.dst); got != test.want {
+			t.Errorf("connected(%d, %d) = %t, want %t", test.src, test.dst, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	handshakeTimeout    = 10 * time.Second
+	dialTimeout         = 10 * time.Second
+	resetCheckFrequency = 100 * time.Millisecond
+)
+
+var errUnknownPeer = errors.New("peer certificate does not belong to a node of the network")
+
+// Node describes the staking port of a node and the proxy placed in front of
+// it
+type Node struct {
+	// Cert is the staking certificate of the node. The proxy presents it to
+	// peers connecting to the node, and to the node when the node is the one
+	// connecting to a peer.
+	Cert tls.Certificate
+	// ListenAddr is the address the node is advertised at and the proxy
+	// listens on
+	ListenAddr string
+	// NodeAddr is the address the staking port of the node listens on
+	NodeAddr string
+}
+
+// Network proxies the peer-to-peer connections between the nodes of a network
+// so that faults can be injected on the links between them. Nodes are
+// numbered from 1.
+type Network struct {
+	nodes []Node
+
+	// links holds the link between every pair of nodes
+	links map[linkKey]*link
+
+	lock      sync.Mutex
+	listeners []net.Listener
+	conns     map[*conn]struct{}
+	partition *Partition
+	healTimer *time.Timer
+}
+
+// conn is a proxied connection from node [src] to node [dst]
+type conn struct {
+	src, dst int
+	in, out  net.Conn
+}
+
+func (c *conn) close() {
+	c.in.Close()
+	c.out.Close()
+}
+
+func New(nodes []Node) *Network {
+	links := make(map[linkKey]*link)
+	for src := 1; src <= len(nodes); src++ {
+		for dst := 1; dst <= len(nodes); dst++ {
+			if src != dst {
+				links[linkKey{src, dst}] = newLink()
+			}
+		}
+	}
+	return &Network{
+		nodes: nodes,
+		links: links,
+		conns: make(map[*conn]struct{}),
+	}
+}
+
+// Start listens on the address of every node and proxies connections until
+// [Stop] is called
+func (n *Network) Start(ctx context.Context) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for i, node := range n.nodes {
+		listener, err := net.Listen("tcp", node.ListenAddr)
+		if err != nil {
+			for _, l := range n.listeners {
+				l.Close()
+			}
+			n.listeners = nil
+			return fmt.Errorf("could not start proxy of node%d: %w", i+1, err)
+		}
+		n.listeners = append(n.listeners, listener)
+		go n.serve(ctx, i+1, listener)
+	}
+	return nil
+}
+
+// Stop closes the listeners and all proxied connections
+func (n *Network) Stop() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, l := range n.listeners {
+		l.Close()
+	}
+	n.listeners = nil
+	for c := range n.conns {
+		c.close()
+	}
+	if n.healTimer != nil {
+		n.healTimer.Stop()
+	}
+}
+
+func (n *Network) serve(ctx context.Context, dst int, listener net.Listener) {
+	for {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go n.handle(ctx, dst, c)
+	}
+}
+
+// handle terminates the TLS connection of a peer connecting to node [dst],
+// identifies the peer by its certificate and connects to [dst] as that peer
+func (n *Network) handle(ctx context.Context, dst int, raw net.Conn) {
+	in := tls.Server(raw, n.tlsConfig(dst))
+	src, err := n.handshake(ctx, in)
+	if err != nil {
+		in.Close()
+		return
+	}
+	if n.blocked(src, dst) {
+		in.Close()
+		return
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: dialTimeout},
+		Config:    n.tlsConfig(src),
+	}
+	out, err := dialer.DialContext(ctx, "tcp", n.nodes[dst-1].NodeAddr)
+	if err != nil {
+		in.Close()
+		return
+	}
+
+	c := &conn{src: src, dst: dst, in: in, out: out}
+	if !n.register(c) {
+		c.close()
+		return
+	}
+	defer n.unregister(c)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go n.resetWhenDue(ctx, c, time.Now())
+
+	done := make(chan struct{}, 2)
+	go func() {
+		n.link(src, dst).forward(ctx, in, out)
+		done <- struct{}{}
+	}()
+	go func() {
+		n.link(dst, src).forward(ctx, out, in)
+		done <- struct{}{}
+	}()
+	// Closing both sides as soon as one of them is done makes the other
+	// direction return too
+	<-done
+	cancel()
+	c.close()
+	<-done
+}
+
+// resetWhenDue closes [c] once it has been open for the reset interval of
+// either of the links it goes through
+func (n *Network) resetWhenDue(ctx context.Context, c *conn, opened time.Time) {
+	ticker := time.NewTicker(resetCheckFrequency)
+	defer ticker.Stop()
+
+	links := []*link{n.link(c.src, c.dst), n.link(c.dst, c.src)}
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		for _, l := range links {
+			interval := time.Duration(l.getConfig().ResetInterval)
+			if interval > 0 && time.Since(opened) >= interval {
+				color.Yellow("resetting connection from node%d to node%d", c.src, c.dst)
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// handshake completes the TLS handshake of [in] and returns the node the peer
+// certificate belongs to
+func (n *Network) handshake(ctx context.Context, in *tls.Conn) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+	if err := in.HandshakeContext(ctx); err != nil {
+		return 0, err
+	}
+	peerCerts := in.ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return 0, errUnknownPeer
+	}
+	for i, node := range n.nodes {
+		if bytes.Equal(node.Cert.Certificate[0], peerCerts[0].Raw) {
+			return i + 1, nil
+		}
+	}
+	return 0, errUnknownPeer
+}
+
+// tlsConfig returns the config used to act as node [nodeNum], which matches
+// the one avalanchego uses for its peers
+func (n *Network) tlsConfig(nodeNum int) *tls.Config {
+	return &tls.Config{
+		Certificates:       []tls.Certificate{n.nodes[nodeNum-1].Cert},
+		ClientAuth:         tls.RequireAnyClientCert,
+		InsecureSkipVerify: true, //#nosec G402
+		MinVersion:         tls.VersionTLS13,
+	}
+}
+
+// register tracks [c] unless the link it goes through was cut in the
+// meantime
+func (n *Network) register(c *conn) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.blockedLocked(c.src, c.dst) {
+		return false
+	}
+	n.conns[c] = struct{}{}
+	return true
+}
+
+func (n *Network) unregister(c *conn) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	delete(n.conns, c)
+}
+
+func (n *Network) blocked(src, dst int) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.blockedLocked(src, dst)
+}
+
+func (n *Network) blockedLocked(src, dst int) bool {
+	return n.partition != nil && !n.partition.connected(src, dst)
+}
+
+// closeBlockedLocked closes the connections going through links that are cut
+func (n *Network) closeBlockedLocked() {
+	for c := range n.conns {
+		if n.blockedLocked(c.src, c.dst) {
+			color.Yellow("closing connection from node%d to node%d", c.src, c.dst)
+			c.close()
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/ava-sim/evm"
+
+	"github.com/ava-labs/avalanchego/genesis"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	"github.com/ava-labs/avalanchego/vms/avm"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/status"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	wallet "github.com/ava-labs/avalanchego/wallet/subnet/primary"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+	ethcommon "github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/ethclient"
+	"github.com/fatih/color"
+)
+
+// probeAmount is sent back to the funded key by every probe tx
+const probeAmount = 1
+
+// ProbeChains issues a tx on the P, X and C chains, and on [blockchainID] when
+// it is set and runs an EVM, through the node at [issuerURI]. It returns once
+// every node in [nodeURIs] accepted all of them, which shows that the chains
+// are still finalizing.
+func ProbeChains(ctx context.Context, issuerURI string, nodeURIs []string, blockchainID string) error {
+	w, err := probeWallet(ctx, issuerURI)
+	if err != nil {
+		return err
+	}
+	if err := probePChain(ctx, w, nodeURIs); err != nil {
+		return err
+	}
+	if err := probeXChain(ctx, w, nodeURIs); err != nil {
+		return err
+	}
+
+	// C-chain and custom chain
+	chains := []string{"C"}
+	if len(blockchainID) > 0 {
+		chains = append(chains, blockchainID)
+	}
+	for _, chain := range chains {
+		if err := probeEVMChain(ctx, issuerURI, nodeURIs, chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProbeChain issues a tx on [chain], which is P, X or the alias or ID of an
+// EVM chain, through the node at [issuerURI], and returns once every node in
+// [nodeURIs] accepted it
+func ProbeChain(ctx context.Context, issuerURI string, nodeURIs []string, chain string) error {
+	if chain != "P" && chain != "X" {
+		return probeEVMChain(ctx, issuerURI, nodeURIs, chain)
+	}
+	w, err := probeWallet(ctx, issuerURI)
+	if err != nil {
+		return err
+	}
+	if chain == "P" {
+		return probePChain(ctx, w, nodeURIs)
+	}
+	return probeXChain(ctx, w, nodeURIs)
+}
+
+// probeWallet returns a wallet of the funded key, which pays for the probes
+func probeWallet(ctx context.Context, issuerURI string) (*wallet.Wallet, error) {
+	kc := secp256k1fx.NewKeychain(genesis.EWOQKey)
+	w, err := wallet.MakeWallet(ctx, issuerURI, kc, kc, wallet.WalletConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("could not create wallet: %w", err)
+	}
+	return w, nil
+}
+
+func probePChain(ctx context.Context, w *wallet.Wallet, nodeURIs []string) error {
+	pTx, err := w.P().IssueBaseTx(
+		[]*avax.TransferableOutput{probeOutput(w.P().Builder().Context().AVAXAssetID)},
+		common.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("could not issue P-chain probe: %w", err)
+	}
+	return waitAllNodes(ctx, nodeURIs, fmt.Sprintf("P-chain probe %s", pTx.ID()), func(ctx context.Context, uri string) bool {
+		txStatus, err := platformvm.NewClient(uri).GetTxStatus(ctx, pTx.ID())
+		if err != nil {
+			return false
+		}
+		return txStatus.Status == status.Committed
+	})
+}
+
+func probeXChain(ctx context.Context, w *wallet.Wallet, nodeURIs []string) error {
+	xTx, err := w.X().IssueBaseTx(
+		[]*avax.TransferableOutput{probeOutput(w.X().Builder().Context().AVAXAssetID)},
+		common.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("could not issue X-chain probe: %w", err)
+	}
+	return waitAllNodes(ctx, nodeURIs, fmt.Sprintf("X-chain probe %s", xTx.ID()), func(ctx context.Context, uri string) bool {
+		txStatus, err := avm.NewClient(uri, "X").GetTxStatus(ctx, xTx.ID())
+		if err != nil {
+			return false
+		}
+		return txStatus == choices.Accepted
+	})
+}
+
+// probeOutput sends [probeAmount] of [assetID] back to the funded key
+func probeOutput(assetID ids.ID) *avax.TransferableOutput {
+	return &avax.TransferableOutput{
+		Asset: avax.Asset{ID: assetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt: probeAmount,
+			OutputOwners: secp256k1fx.OutputOwners{
+				Threshold: 1,
+				Addrs: []ids.ShortID{
+					genesis.EWOQKey.PublicKey().Address(),
+				},
+			},
+		},
+	}
+}
+
+func probeEVMChain(ctx context.Context, issuerURI string, nodeURIs []string, chain string) error {
+	client, err := ethclient.DialContext(ctx, evm.RPCURL(issuerURI, chain))
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %w", chain, err)
+	}
+	defer client.Close()
+
+	if _, err := client.ChainID(ctx); err != nil {
+		color.Yellow("skipping probe of %s: not an EVM chain (%v)", chain, err)
+		return nil
+	}
+
+	key := evm.FundedKey()
+	tx, err := evm.Transfer(ctx, client, key, evm.Address(key), big.NewInt(probeAmount))
+	if err != nil {
+		return fmt.Errorf("could not issue %s probe: %w", chain, err)
+	}
+	return waitAllNodes(ctx, nodeURIs, fmt.Sprintf("%s probe %s", chain, tx.Hash()), func(ctx context.Context, uri string) bool {
+		nodeClient, err := ethclient.DialContext(ctx, evm.RPCURL(uri, chain))
+		if err != nil {
+			return false
+		}
+		defer nodeClient.Close()
+
+		receipt, err := nodeClient.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return false
+		}
+		return receipt.BlockHash != (ethcommon.Hash{})
+	})
+}
+
+// waitAllNodes polls [accepted] for every node until all of them report the
+// probe described by [desc] as accepted
+func waitAllNodes(
+	ctx context.Context,
+	nodeURIs []string,
+	desc string,
+	accepted func(ctx context.Context, uri string) bool,
+) error {
+	for _, uri := range nodeURIs {
+		for {
+			if accepted(ctx, uri) {
+				break
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("%s not accepted by %s: %w", desc, uri, ctx.Err())
+			}
+			color.Yellow("waiting for %s to accept %s", uri, desc)
+			time.Sleep(waitTime)
+		}
+	}
+	color.Cyan("%s accepted by all nodes", desc)
+	return nil
+}
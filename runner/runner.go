@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/events"
 	"github.com/ava-labs/ava-sim/manager"
 
 	"github.com/ava-labs/avalanchego/api/info"
@@ -17,6 +18,7 @@ import (
 	"github.com/ava-labs/avalanchego/vms/platformvm/status"
 	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
 	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	walletsigner "github.com/ava-labs/avalanchego/wallet/chain/p/signer"
 	wallet "github.com/ava-labs/avalanchego/wallet/subnet/primary"
 	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
 	"github.com/fatih/color"
@@ -33,7 +35,11 @@ const (
 	validatorEndDiff   = 15 * 24 * time.Hour
 )
 
-func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
+// SetupSubnet returns the ID of the created blockchain once all nodes are
+// validating it. The debug APIs of the blockchain, if [network] enables them,
+// are set up before it is created. The txs it issues and the progress of every
+// node are recorded in [log].
+func SetupSubnet(ctx context.Context, network *manager.Network, vmID ids.ID, vmGenesis string, log *events.Log) (ids.ID, error) {
 	color.Cyan("creating subnet")
 	var (
 		nodeURLs = manager.NodeURLs()
@@ -62,12 +68,13 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 	// Create a subnet
 	subnetIDTx, err := pWallet.IssueCreateSubnetTx(owner)
 	if err != nil {
-		return fmt.Errorf("unable to create subnet: %w", err)
+		return ids.Empty, fmt.Errorf("unable to create subnet: %w", err)
 	}
+	emitTx(log, events.TxIssued, "subnet creation", subnetIDTx.TxID)
 
 	for {
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return ids.Empty, ctx.Err()
 		}
 		txStatus, _ := client.GetTxStatus(ctx, subnetIDTx.TxID)
 		if txStatus.Status == status.Committed {
@@ -77,16 +84,17 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 		time.Sleep(waitTime)
 	}
 	color.Cyan("subnet creation tx (%s) accepted", subnetIDTx)
+	emitTx(log, events.TxCommitted, "subnet creation", subnetIDTx.TxID)
 
 	// Confirm created subnet appears in subnet list
 	subnets, err := client.GetSubnets(ctx, []ids.ID{})
 	if err != nil {
-		return fmt.Errorf("cannot query subnets: %w", err)
+		return ids.Empty, fmt.Errorf("cannot query subnets: %w", err)
 	}
 	rSubnetID := subnets[0].ID
 	subnetID := rSubnetID.String()
 	if subnetID != constants.WhitelistedSubnets {
-		return fmt.Errorf("expected subnet %s but got %s", constants.WhitelistedSubnets, subnetID)
+		return ids.Empty, fmt.Errorf("expected subnet %s but got %s", constants.WhitelistedSubnets, subnetID)
 	}
 
 	// Add all validators to subnet with equal weight
@@ -94,7 +102,7 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 		nodeID, err := ids.NodeIDFromString(nodeIDStr)
 		if err != nil {
 			fmt.Println(err)
-			return err
+			return ids.Empty, err
 		}
 
 		tx, err := pWallet.IssueAddSubnetValidatorTx(
@@ -110,12 +118,13 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 			common.WithContext(ctx),
 		)
 		if err != nil {
-			return fmt.Errorf("unable to add subnet validator: %w", err)
+			return ids.Empty, fmt.Errorf("unable to add subnet validator: %w", err)
 		}
+		emitTx(log, events.TxIssued, fmt.Sprintf("add subnet validator (%s)", nodeID), tx.TxID)
 
 		for {
 			if ctx.Err() != nil {
-				return ctx.Err()
+				return ids.Empty, ctx.Err()
 			}
 			txStatus, _ := client.GetTxStatus(ctx, tx.TxID)
 			if txStatus.Status == status.Committed {
@@ -125,15 +134,18 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 			time.Sleep(waitTime)
 		}
 		color.Cyan("add subnet validator (%s) tx (%s) accepted", nodeID, tx.TxID)
+		emitTx(log, events.TxCommitted, fmt.Sprintf("add subnet validator (%s)", nodeID), tx.TxID)
 	}
 
 	// Create blockchain
 	genesis, err := ioutil.ReadFile(vmGenesis)
 	if err != nil {
-		return fmt.Errorf("could not read genesis file (%s): %w", vmGenesis, err)
+		return ids.Empty, fmt.Errorf("could not read genesis file (%s): %w", vmGenesis, err)
 	}
 
-	createTx, err := pWallet.IssueCreateChainTx(
+	// The tx is signed ahead of being issued, as the blockchain is named after
+	// it and its config must be in place before nodes create it
+	createUTx, err := pWallet.Builder().NewCreateChainTx(
 		rSubnetID,
 		genesis,
 		vmID,
@@ -141,11 +153,22 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 		constants.VMName,
 	)
 	if err != nil {
-		return fmt.Errorf("could not create blockchain: %w", err)
+		return ids.Empty, fmt.Errorf("could not create blockchain: %w", err)
 	}
+	createTx, err := walletsigner.SignUnsigned(ctx, pWallet.Signer(), createUTx)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("could not sign blockchain creation: %w", err)
+	}
+	if err := network.EnableDebugAPIs(ctx, createTx.ID()); err != nil {
+		return ids.Empty, fmt.Errorf("could not enable the debug APIs of the blockchain: %w", err)
+	}
+	if err := pWallet.IssueTx(createTx, common.WithContext(ctx)); err != nil {
+		return ids.Empty, fmt.Errorf("could not create blockchain: %w", err)
+	}
+	emitTx(log, events.TxIssued, "create blockchain", createTx.TxID)
 	for {
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return ids.Empty, ctx.Err()
 		}
 		txStatus, _ := client.GetTxStatus(ctx, createTx.TxID)
 		if txStatus.Status == status.Committed {
@@ -155,11 +178,12 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 		time.Sleep(waitTime)
 	}
 	color.Cyan("create blockchain tx (%s) accepted", createTx.TxID)
+	emitTx(log, events.TxCommitted, "create blockchain", createTx.TxID)
 
 	// Validate blockchain exists
 	blockchains, err := client.GetBlockchains(ctx)
 	if err != nil {
-		return fmt.Errorf("could not query blockchains: %w", err)
+		return ids.Empty, fmt.Errorf("could not query blockchains: %w", err)
 	}
 	var blockchainID ids.ID
 	for _, blockchain := range blockchains {
@@ -169,7 +193,7 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 		}
 	}
 	if blockchainID == (ids.ID{}) {
-		return errors.New("could not find blockchain")
+		return ids.Empty, errors.New("could not find blockchain")
 	}
 
 	// Ensure all nodes are validating subnet
@@ -177,7 +201,7 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 		nClient := platformvm.NewClient(url)
 		for {
 			if ctx.Err() != nil {
-				return ctx.Err()
+				return ids.Empty, ctx.Err()
 			}
 			txStatus, _ := nClient.GetBlockchainStatus(ctx, blockchainID.String())
 			if txStatus == status.Validating {
@@ -187,6 +211,13 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 			time.Sleep(longWaitTime)
 		}
 		color.Cyan("%s validating blockchain %s", nodeIDs[i], blockchainID)
+		log.Emit(events.Event{
+			Type:    events.ChainValidating,
+			Message: fmt.Sprintf("%s validating blockchain %s", nodeIDs[i], blockchainID),
+			Node:    i + 1,
+			NodeID:  nodeIDs[i],
+			Chain:   blockchainID.String(),
+		})
 	}
 
 	// Ensure network bootstrapped
@@ -194,7 +225,7 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 		nClient := info.NewClient(url)
 		for {
 			if ctx.Err() != nil {
-				return ctx.Err()
+				return ids.Empty, ctx.Err()
 			}
 			bootstrapped, _ := nClient.IsBootstrapped(ctx, blockchainID.String())
 			if bootstrapped {
@@ -204,13 +235,43 @@ func SetupSubnet(ctx context.Context, vmID ids.ID, vmGenesis string) error {
 			time.Sleep(waitTime)
 		}
 		color.Cyan("%s bootstrapped %s", nodeIDs[i], blockchainID)
+		log.Emit(events.Event{
+			Type:    events.NodeBootstrapped,
+			Message: fmt.Sprintf("%s bootstrapped %s", nodeIDs[i], blockchainID),
+			Node:    i + 1,
+			NodeID:  nodeIDs[i],
+			Chain:   blockchainID.String(),
+		})
 	}
 
 	// Print endpoints where VM is accessible
 	color.Green("Custom VM endpoints now accessible at:")
 	for i, url := range nodeURLs {
-		color.Green("%s: %s/ext/bc/%s", nodeIDs[i], url, blockchainID.String())
+		uri := fmt.Sprintf("%s/ext/bc/%s", url, blockchainID)
+		color.Green("%s: %s", nodeIDs[i], uri)
+		log.Emit(events.Event{
+			Type:    events.EndpointsReady,
+			Message: fmt.Sprintf("custom VM endpoints of %s accessible at %s", nodeIDs[i], uri),
+			Node:    i + 1,
+			NodeID:  nodeIDs[i],
+			Chain:   blockchainID.String(),
+			URI:     uri,
+		})
 	}
 	color.Green("Custom VM ID: %s", vmID)
-	return nil
+	return blockchainID, nil
+}
+
+// emitTx records a P-chain tx described by [desc] reaching a new state
+func emitTx(log *events.Log, eventType string, desc string, txID ids.ID) {
+	state := "issued"
+	if eventType == events.TxCommitted {
+		state = "committed"
+	}
+	log.Emit(events.Event{
+		Type:    eventType,
+		Message: fmt.Sprintf("%s tx (%s) %s", desc, txID, state),
+		Chain:   "P",
+		TxID:    txID.String(),
+	})
 }
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ava-labs/ava-sim/chaos"
+	"github.com/ava-labs/ava-sim/events"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/monitor"
+	"github.com/ava-labs/ava-sim/proxy"
+)
+
+// ReadyReply is the response of the /ready endpoint
+type ReadyReply struct {
+	Ready   bool                 `json:"ready"`
+	Network *manager.NetworkInfo `json:"network,omitempty"`
+}
+
+// PartitionArgs are the arguments of the /faults/partition endpoint
+type PartitionArgs struct {
+	// Groups lists the nodes (starting at 1) of every group. Nodes missing
+	// from all groups form one more group.
+	Groups [][]int `json:"groups"`
+	// Duration after which the partition is healed, e.g. "2m". The partition
+	// stays until healed explicitly if it is empty.
+	Duration string `json:"duration,omitempty"`
+}
+
+// LinkArgs are the arguments of the /faults/links endpoint
+type LinkArgs struct {
+	// From and To list the nodes (starting at 1) the config applies to the
+	// links between. Empty lists stand for all nodes.
+	From []int `json:"from,omitempty"`
+	To   []int `json:"to,omitempty"`
+	// OneWay only applies the config to the links from [From] to [To], not
+	// to the ones going back
+	OneWay bool             `json:"oneWay,omitempty"`
+	Config proxy.LinkConfig `json:"config"`
+}
+
+// FaultsReply describes the faults currently injected into the network
+type FaultsReply struct {
+	Partition *proxy.Partition `json:"partition,omitempty"`
+	// Links lists the links that are shaped or faulty
+	Links []proxy.Link `json:"links,omitempty"`
+}
+
+// Client talks to the API of a running ava-sim
+type Client struct {
+	uri        string
+	httpClient *http.Client
+}
+
+func NewClient(uri string) *Client {
+	return &Client{
+		uri:        uri,
+		httpClient: &http.Client{},
+	}
+}
+
+// Ready returns whether the network is ready, and its info if it is
+func (c *Client) Ready(ctx context.Context) (ReadyReply, error) {
+	var reply ReadyReply
+	// /ready answers with 503 until the network is ready, which is not an
+	// error for the caller
+	err := c.get(ctx, "/ready", &reply, http.StatusServiceUnavailable)
+	return reply, err
+}
+
+// Network returns the info of the network, which may not be ready yet
+func (c *Client) Network(ctx context.Context) (manager.NetworkInfo, error) {
+	var info manager.NetworkInfo
+	err := c.get(ctx, "/network", &info)
+	return info, err
+}
+
+// AddContract records [contract] in the network info, and returns the
+// updated info
+func (c *Client) AddContract(ctx context.Context, contract manager.ContractInfo) (manager.NetworkInfo, error) {
+	var info manager.NetworkInfo
+	err := c.post(ctx, "/contracts", contract, &info)
+	return info, err
+}
+
+// Nodes returns the status of every node
+func (c *Client) Nodes(ctx context.Context) ([]manager.NodeStatus, error) {
+	var statuses []manager.NodeStatus
+	err := c.get(ctx, "/nodes", &statuses)
+	return statuses, err
+}
+
+// RestartNode restarts node [nodeNum] (starting at 1), replacing its binary
+// with [binary] if it is set, and returns its status once it is started again
+func (c *Client) RestartNode(ctx context.Context, nodeNum int, binary string) (manager.NodeStatus, error) {
+	params := url.Values{}
+	params.Set("node", strconv.Itoa(nodeNum))
+	if len(binary) > 0 {
+		params.Set("avalanchego-path", binary)
+	}
+	var status manager.NodeStatus
+	err := c.post(ctx, "/nodes/restart?"+params.Encode(), nil, &status)
+	return status, err
+}
+
+// StopNode stops node [nodeNum] (starting at 1) until [StartNode] is called
+func (c *Client) StopNode(ctx context.Context, nodeNum int) (manager.NodeStatus, error) {
+	var status manager.NodeStatus
+	err := c.post(ctx, "/nodes/stop?node="+strconv.Itoa(nodeNum), nil, &status)
+	return status, err
+}
+
+// StartNode starts node [nodeNum] (starting at 1) after [StopNode]
+func (c *Client) StartNode(ctx context.Context, nodeNum int) (manager.NodeStatus, error) {
+	var status manager.NodeStatus
+	err := c.post(ctx, "/nodes/start?node="+strconv.Itoa(nodeNum), nil, &status)
+	return status, err
+}
+
+// Events returns the events recorded since the network started
+func (c *Client) Events(ctx context.Context) ([]events.Event, error) {
+	var reply []events.Event
+	err := c.get(ctx, "/events", &reply)
+	return reply, err
+}
+
+// RunScenario runs [scenario] against the network and returns the status of
+// every node once it is over
+func (c *Client) RunScenario(ctx context.Context, scenario chaos.Scenario) ([]manager.NodeStatus, error) {
+	var statuses []manager.NodeStatus
+	err := c.post(ctx, "/chaos", scenario, &statuses)
+	return statuses, err
+}
+
+// Monitor returns the report of the safety and liveness monitor
+func (c *Client) Monitor(ctx context.Context) (monitor.Report, error) {
+	var report monitor.Report
+	err := c.get(ctx, "/monitor", &report)
+	return report, err
+}
+
+// Faults returns the faults currently injected into the network
+func (c *Client) Faults(ctx context.Context) (FaultsReply, error) {
+	var reply FaultsReply
+	err := c.get(ctx, "/faults", &reply)
+	return reply, err
+}
+
+// Partition splits the network into [groups] of nodes, healing it after
+// [duration] unless it is 0
+func (c *Client) Partition(ctx context.Context, groups [][]int, duration time.Duration) (FaultsReply, error) {
+	args := PartitionArgs{Groups: groups}
+	if duration > 0 {
+		args.Duration = duration.String()
+	}
+	var reply FaultsReply
+	err := c.post(ctx, "/faults/partition", args, &reply)
+	return reply, err
+}
+
+// Heal removes the partition of the network, if any
+func (c *Client) Heal(ctx context.Context) (FaultsReply, error) {
+	var reply FaultsReply
+	err := c.post(ctx, "/faults/heal", nil, &reply)
+	return reply, err
+}
+
+// SetLinks applies [args] to the links between nodes
+func (c *Client) SetLinks(ctx context.Context, args LinkArgs) (FaultsReply, error) {
+	var reply FaultsReply
+	err := c.post(ctx, "/faults/links", args, &reply)
+	return reply, err
+}
+
+// ResetLinks removes the config of every link
+func (c *Client) ResetLinks(ctx context.Context) (FaultsReply, error) {
+	var reply FaultsReply
+	err := c.post(ctx, "/faults/links/reset", nil, &reply)
+	return reply, err
+}
+
+func (c *Client) get(ctx context.Context, path string, reply interface{}, okStatuses ...int) error {
+	return c.do(ctx, http.MethodGet, path, nil, reply, okStatuses)
+}
+
+func (c *Client) post(ctx context.Context, path string, args interface{}, reply interface{}) error {
+	return c.do(ctx, http.MethodPost, path, args, reply, nil)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, args interface{}, reply interface{}, okStatuses []int) error {
+	var body io.Reader
+	if args != nil {
+		argsBytes, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(argsBytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.uri+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !statusOK(resp.StatusCode, okStatuses) {
+		var errReply ErrorReply
+		if err := json.NewDecoder(resp.Body).Decode(&errReply); err == nil && len(errReply.Error) > 0 {
+			return errors.New(errReply.Error)
+		}
+		return fmt.Errorf("%s returned status %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
+
+func statusOK(status int, okStatuses []int) bool {
+	if status == http.StatusOK {
+		return true
+	}
+	for _, s := range okStatuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,422 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/chaos"
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/events"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/metrics"
+	"github.com/ava-labs/ava-sim/monitor"
+	"github.com/ava-labs/ava-sim/proxy"
+	"github.com/ava-labs/ava-sim/utils"
+
+	"github.com/fatih/color"
+)
+
+// ErrChaosRunning is returned when a chaos scenario is started while another
+// one runs
+var ErrChaosRunning = errors.New("another chaos scenario is running")
+
+// Server exposes the state of the local network over HTTP so that external
+// tooling doesn't have to scrape stdout
+type Server struct {
+	mux     *http.ServeMux
+	network *manager.Network
+	events  *events.Log
+
+	// chaosLock is held while a chaos scenario runs
+	chaosLock sync.Mutex
+
+	lock    sync.RWMutex
+	info    manager.NetworkInfo
+	ready   bool
+	monitor *monitor.Monitor
+	// writeInfo persists the network info once it changes after the network
+	// is ready
+	writeInfo func(manager.NetworkInfo) error
+}
+
+func New(network *manager.Network, log *events.Log) *Server {
+	s := &Server{
+		mux:     http.NewServeMux(),
+		network: network,
+		events:  log,
+	}
+	s.mux.HandleFunc("/ready", s.handleReady)
+	s.mux.HandleFunc("/network", s.handleNetwork)
+	s.mux.HandleFunc("/contracts", s.handleAddContract)
+	s.mux.HandleFunc("/nodes", s.handleNodes)
+	s.mux.HandleFunc("/nodes/restart", s.handleRestartNode)
+	s.mux.HandleFunc("/nodes/stop", s.handleStopNode)
+	s.mux.HandleFunc("/nodes/start", s.handleStartNode)
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/chaos", s.handleChaos)
+	s.mux.HandleFunc("/monitor", s.handleMonitor)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	s.mux.HandleFunc("/faults", s.handleFaults)
+	s.mux.HandleFunc("/faults/partition", s.handlePartition)
+	s.mux.HandleFunc("/faults/heal", s.handleHeal)
+	s.mux.HandleFunc("/faults/links", s.handleSetLinks)
+	s.mux.HandleFunc("/faults/links/reset", s.handleResetLinks)
+	return s
+}
+
+// SetInfo updates the network info served by the API
+func (s *Server) SetInfo(info manager.NetworkInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.info = info
+}
+
+// SetReady marks the network as ready to be used
+func (s *Server) SetReady(info manager.NetworkInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.info = info
+	s.ready = true
+}
+
+// SetInfoWriter sets the function persisting the network info when contracts
+// are added to it
+func (s *Server) SetInfoWriter(writeInfo func(manager.NetworkInfo) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.writeInfo = writeInfo
+}
+
+// SetMonitor makes the report of [m] available on the API
+func (s *Server) SetMonitor(m *monitor.Monitor) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.monitor = m
+}
+
+// Run serves the API until [ctx] is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", constants.APIPort))
+	if err != nil {
+		return fmt.Errorf("could not start ava-sim API: %w", err)
+	}
+	httpServer := &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: constants.HTTPTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	color.Cyan("ava-sim API listening at: %s", constants.APIURL)
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if !s.ready {
+		writeJSON(w, http.StatusServiceUnavailable, ReadyReply{Ready: false})
+		return
+	}
+	info := s.info
+	writeJSON(w, http.StatusOK, ReadyReply{Ready: true, Network: &info})
+}
+
+func (s *Server) handleNetwork(w http.ResponseWriter, _ *http.Request) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	writeJSON(w, http.StatusOK, s.info)
+}
+
+// handleAddContract records the posted contract in the network info
+func (s *Server) handleAddContract(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+		return
+	}
+	var contract manager.ContractInfo
+	if err := json.NewDecoder(r.Body).Decode(&contract); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid contract: %w", err))
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.ready {
+		writeError(w, http.StatusServiceUnavailable, errors.New("network is not ready"))
+		return
+	}
+	info := s.info
+	info.Contracts = append(append([]manager.ContractInfo(nil), s.info.Contracts...), contract)
+	if s.writeInfo != nil {
+		if err := s.writeInfo(info); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	s.info = info
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleNodes(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.network.Status())
+}
+
+// handleRestartNode restarts ?node=N, optionally replacing its binary with
+// ?avalanchego-path=..., and returns once the node is started again
+func (s *Server) handleRestartNode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+		return
+	}
+	nodeNum, err := strconv.Atoi(r.URL.Query().Get("node"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid node: %w", err))
+		return
+	}
+	// The binary is checked before the node is stopped, so a bad path doesn't
+	// leave it down
+	binary := r.URL.Query().Get("avalanchego-path")
+	if len(binary) > 0 {
+		if err := utils.CheckExecutable(binary); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid avalanchego binary: %w", err))
+			return
+		}
+	}
+	if err := s.network.RestartNode(nodeNum, binary); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.network.Status()[nodeNum-1])
+}
+
+// handleStopNode stops ?node=N until it is started again
+func (s *Server) handleStopNode(w http.ResponseWriter, r *http.Request) {
+	s.handleNodeAction(w, r, s.network.StopNode)
+}
+
+// handleStartNode starts ?node=N after it was stopped
+func (s *Server) handleStartNode(w http.ResponseWriter, r *http.Request) {
+	s.handleNodeAction(w, r, s.network.StartNode)
+}
+
+func (s *Server) handleNodeAction(w http.ResponseWriter, r *http.Request, action func(nodeNum int) error) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+		return
+	}
+	nodeNum, err := strconv.Atoi(r.URL.Query().Get("node"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid node: %w", err))
+		return
+	}
+	if err := action(nodeNum); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.network.Status()[nodeNum-1])
+}
+
+func (s *Server) handleMonitor(w http.ResponseWriter, _ *http.Request) {
+	s.lock.RLock()
+	m := s.monitor
+	s.lock.RUnlock()
+
+	if m == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("monitor is not running"))
+		return
+	}
+	writeJSON(w, http.StatusOK, m.Report())
+}
+
+// handleMetrics serves the metrics of every node, tagged with its node ID
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.lock.RLock()
+	targets := make([]metrics.Target, len(s.info.Nodes))
+	for i, node := range s.info.Nodes {
+		targets[i] = metrics.Target{NodeID: node.ID, URI: node.URI}
+	}
+	s.lock.RUnlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), constants.HTTPTimeout)
+	defer cancel()
+	families := metrics.Gather(ctx, targets)
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if err := metrics.Write(w, families); err != nil {
+		color.Red("could not write metrics: %s", err)
+	}
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.events.Events())
+}
+
+// handleChaos runs the posted chaos scenario and returns once it is over. The
+// scenario is interrupted if the request is cancelled.
+func (s *Server) handleChaos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+		return
+	}
+	var scenario chaos.Scenario
+	if err := json.NewDecoder(r.Body).Decode(&scenario); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid scenario: %w", err))
+		return
+	}
+	if err := scenario.Verify(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.RunChaos(r.Context(), scenario); errors.Is(err, ErrChaosRunning) {
+		writeError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.network.Status())
+}
+
+// RunChaos runs [scenario] against the network and returns once it is over,
+// or [ErrChaosRunning] if another scenario is running
+func (s *Server) RunChaos(ctx context.Context, scenario chaos.Scenario) error {
+	if !s.chaosLock.TryLock() {
+		return ErrChaosRunning
+	}
+	defer s.chaosLock.Unlock()
+
+	return chaos.Run(ctx, s.network, scenario, s.events)
+}
+
+func (s *Server) handleFaults(w http.ResponseWriter, _ *http.Request) {
+	links, err := s.network.Links()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, faultsReply(links))
+}
+
+// handlePartition splits the network into the groups of [PartitionArgs]
+func (s *Server) handlePartition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+		return
+	}
+	var args PartitionArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid partition: %w", err))
+		return
+	}
+	var duration time.Duration
+	if len(args.Duration) > 0 {
+		var err error
+		duration, err = time.ParseDuration(args.Duration)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid duration: %w", err))
+			return
+		}
+	}
+	links, err := s.network.Links()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := links.Partition(args.Groups, duration); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, faultsReply(links))
+}
+
+func (s *Server) handleHeal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+		return
+	}
+	links, err := s.network.Links()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	links.Heal()
+	writeJSON(w, http.StatusOK, faultsReply(links))
+}
+
+// handleSetLinks applies the config of [LinkArgs] to the links between nodes
+func (s *Server) handleSetLinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+		return
+	}
+	var args LinkArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid link config: %w", err))
+		return
+	}
+	links, err := s.network.Links()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := links.SetLinks(args.From, args.To, args.OneWay, args.Config); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, faultsReply(links))
+}
+
+func (s *Server) handleResetLinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+		return
+	}
+	links, err := s.network.Links()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	links.ResetLinks()
+	writeJSON(w, http.StatusOK, faultsReply(links))
+}
+
+func faultsReply(links *proxy.Network) FaultsReply {
+	return FaultsReply{
+		Partition: links.CurrentPartition(),
+		Links:     links.Links(),
+	}
+}
+
+// ErrorReply is returned by the API when a request fails
+type ErrorReply struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorReply{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,456 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/ava-sim/constants"
+	"github.com/ava-labs/ava-sim/evm"
+	"github.com/ava-labs/ava-sim/logs"
+	"github.com/ava-labs/ava-sim/manager"
+	"github.com/ava-labs/ava-sim/monitor"
+	"github.com/ava-labs/ava-sim/server"
+
+	"github.com/ava-labs/avalanchego/api/health"
+	"github.com/ava-labs/avalanchego/api/info"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/fatih/color"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/term"
+)
+
+const (
+	refreshFrequency = 2 * time.Second
+	// logViewLines is how many log lines of the selected node are shown
+	logViewLines = 15
+	// chainColumnWidth is the width of the column of every chain, whose name
+	// is cut to fit
+	chainColumnWidth = 10
+
+	// Escape sequences switching to the alternate screen and back, hiding the
+	// cursor while the dashboard is shown
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	exitScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen = "\x1b[H\x1b[2J"
+
+	keyCtrlC = "\x03"
+	keyUp    = "\x1b[A"
+	keyDown  = "\x1b[B"
+)
+
+// nodeState is what the dashboard shows about a node
+type nodeState struct {
+	status manager.NodeStatus
+	// reachable is set when the node answered its info API
+	reachable    bool
+	bootstrapped map[string]bool
+	heights      map[string]uint64
+	peers        int
+	healthy      bool
+}
+
+// Dashboard shows the state of every node of a running network and controls
+// them through the ava-sim API
+type Dashboard struct {
+	client *server.Client
+
+	lock sync.Mutex
+	// info and chains are refreshed with the node states, as the network
+	// may be restarted with another custom chain
+	info   manager.NetworkInfo
+	chains []monitor.Chain
+	// evmVMs holds the IDs of the VMs known to run an EVM
+	evmVMs    map[string]bool
+	nodes     []nodeState
+	refreshed time.Time
+	selected  int
+	message   string
+	// logView is set while the logs of the selected node are shown
+	logView  bool
+	logs     *logs.Tailer
+	logLines []string
+}
+
+// New creates a dashboard of the network described by [info], served by the
+// ava-sim API [client] talks to
+func New(client *server.Client, info manager.NetworkInfo) *Dashboard {
+	d := &Dashboard{
+		client: client,
+		info:   info,
+		evmVMs: map[string]bool{evm.SubnetEVMID: true},
+		nodes:  make([]nodeState, len(info.Nodes)),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.HTTPTimeout)
+	defer cancel()
+	d.chains = d.chainsOf(ctx, info)
+	return d
+}
+
+// chainsOf returns the chains of [info] shown by the dashboard. The custom
+// chain is read as an EVM if its VM is known to run one, or once it serves
+// the Ethereum JSON-RPC API.
+func (d *Dashboard) chainsOf(ctx context.Context, info manager.NetworkInfo) []monitor.Chain {
+	chains := []monitor.Chain{{Name: "P"}, {Name: "X"}, {Name: "C"}}
+	if info.Subnet == nil {
+		return chains
+	}
+	d.lock.Lock()
+	isEVM := d.evmVMs[info.Subnet.VMID]
+	d.lock.Unlock()
+	if !isEVM && len(info.Nodes) > 0 && evm.IsEVM(ctx, info.Nodes[0].URI, info.Subnet.BlockchainID) {
+		isEVM = true
+		d.lock.Lock()
+		d.evmVMs[info.Subnet.VMID] = true
+		d.lock.Unlock()
+	}
+	return append(chains, monitor.Chain{Name: info.Subnet.BlockchainID, EVM: isEVM})
+}
+
+// Run shows the dashboard until the user quits or [ctx] is cancelled
+func (d *Dashboard) Run(ctx context.Context) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("the dashboard needs a terminal")
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, oldState)
+	fmt.Print(enterScreen)
+	defer fmt.Print(exitScreen)
+
+	// The reader is left blocked on stdin when the dashboard exits
+	keys := make(chan string)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			keys <- string(buf[:n])
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	redraw := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(refreshFrequency)
+		defer ticker.Stop()
+		for {
+			d.refresh(ctx)
+			select {
+			case redraw <- struct{}{}:
+			default:
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		d.draw()
+		select {
+		case key := <-keys:
+			if !d.handleKey(ctx, key, redraw) {
+				return nil
+			}
+		case <-redraw:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// handleKey acts on [key] and returns false once the user quits. Node actions
+// run in the background and trigger a redraw when they are done.
+func (d *Dashboard) handleKey(ctx context.Context, key string, redraw chan struct{}) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var (
+		nodeNum = d.selected + 1
+		action  func(context.Context, int) error
+		// progress and done describe the action while it runs and once it
+		// succeeded
+		progress, done string
+	)
+	switch key {
+	case "q", keyCtrlC:
+		return false
+	case keyUp, "k":
+		d.selectLocked((d.selected + len(d.nodes) - 1) % len(d.nodes))
+	case keyDown, "j":
+		d.selectLocked((d.selected + 1) % len(d.nodes))
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if n := int(key[0] - '1'); n < len(d.nodes) {
+			d.selectLocked(n)
+		}
+	case "l":
+		d.logView = !d.logView
+		d.readLogsLocked()
+	case "s":
+		progress, done = "stopping", "stopped"
+		action = func(ctx context.Context, n int) error {
+			_, err := d.client.StopNode(ctx, n)
+			return err
+		}
+	case "t":
+		progress, done = "starting", "started"
+		action = func(ctx context.Context, n int) error {
+			_, err := d.client.StartNode(ctx, n)
+			return err
+		}
+	case "r":
+		progress, done = "restarting", "restarted"
+		action = func(ctx context.Context, n int) error {
+			_, err := d.client.RestartNode(ctx, n, "")
+			return err
+		}
+	}
+	if action == nil {
+		return true
+	}
+
+	d.message = fmt.Sprintf("%s node%d...", progress, nodeNum)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		message := fmt.Sprintf("node%d %s", nodeNum, done)
+		if err := action(ctx, nodeNum); err != nil {
+			message = color.RedString("node%d: %s", nodeNum, err)
+		}
+		d.lock.Lock()
+		d.message = message
+		d.lock.Unlock()
+		select {
+		case redraw <- struct{}{}:
+		default:
+		}
+	}()
+	return true
+}
+
+func (d *Dashboard) selectLocked(node int) {
+	if node == d.selected {
+		return
+	}
+	d.selected = node
+	d.logs = nil
+	d.logLines = nil
+	d.readLogsLocked()
+}
+
+// readLogsLocked reads the lines logged by the selected node since the last
+// call, if its logs are shown
+func (d *Dashboard) readLogsLocked() {
+	if !d.logView {
+		return
+	}
+	if d.logs == nil {
+		logDirs := make([]string, len(d.info.Nodes))
+		for i, node := range d.info.Nodes {
+			logDirs[i] = node.LogDir
+		}
+		d.logs = logs.New(logDirs, logs.Filter{
+			Nodes: []int{d.selected + 1},
+			Level: logging.Verbo,
+		})
+	}
+	lines, err := d.logs.Read()
+	if err != nil {
+		d.message = color.RedString("could not read logs: %s", err)
+		return
+	}
+	for _, line := range lines {
+		d.logLines = append(d.logLines, fmt.Sprintf("%-*s %s", chainColumnWidth, shorten(line.Chain), line.Text))
+	}
+	if len(d.logLines) > logViewLines {
+		d.logLines = d.logLines[len(d.logLines)-logViewLines:]
+	}
+}
+
+// refresh queries the ava-sim API and every node
+func (d *Dashboard) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, refreshFrequency)
+	defer cancel()
+
+	d.lock.Lock()
+	info := d.info
+	d.lock.Unlock()
+	newInfo, infoErr := d.client.Network(ctx)
+	if infoErr == nil {
+		info = newInfo
+	}
+	chains := d.chainsOf(ctx, info)
+
+	statuses, statusErr := d.client.Nodes(ctx)
+	nodes := make([]nodeState, len(info.Nodes))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, node := range info.Nodes {
+		i, uri := i, node.URI
+		var status manager.NodeStatus
+		if i < len(statuses) {
+			status = statuses[i]
+		}
+		g.Go(func() error {
+			nodes[i] = queryNode(gctx, uri, status, chains)
+			return nil
+		})
+	}
+	g.Wait()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if info.Dir != d.info.Dir {
+		// The logs of the previous network are gone
+		d.logs = nil
+		d.logLines = nil
+	}
+	d.info = info
+	d.chains = chains
+	d.nodes = nodes
+	if d.selected >= len(nodes) {
+		d.selected = 0
+	}
+	d.refreshed = time.Now()
+	switch {
+	case statusErr != nil:
+		d.message = color.RedString("could not reach ava-sim: %s", statusErr)
+	case infoErr != nil:
+		d.message = color.RedString("could not get the network info: %s", infoErr)
+	}
+	d.readLogsLocked()
+}
+
+func queryNode(ctx context.Context, uri string, status manager.NodeStatus, chains []monitor.Chain) nodeState {
+	state := nodeState{
+		status:       status,
+		bootstrapped: make(map[string]bool),
+		heights:      make(map[string]uint64),
+	}
+	infoClient := info.NewClient(uri)
+	peers, err := infoClient.Peers(ctx, nil)
+	if err != nil {
+		return state
+	}
+	state.reachable = true
+	state.peers = len(peers)
+	if reply, err := health.NewClient(uri).Health(ctx, nil); err == nil {
+		state.healthy = reply.Healthy
+	}
+	for _, chain := range chains {
+		state.bootstrapped[chain.Name], _ = infoClient.IsBootstrapped(ctx, chain.Name)
+		if height, err := monitor.Height(ctx, uri, chain); err == nil {
+			state.heights[chain.Name] = height
+		}
+	}
+	return state
+}
+
+func (d *Dashboard) draw() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	updated := "-"
+	if !d.refreshed.IsZero() {
+		updated = d.refreshed.Format("15:04:05")
+	}
+	fmt.Fprintf(&b, "%s  %s  updated %s\n\n", color.CyanString("ava-sim"), d.info.Dir, updated)
+
+	header := fmt.Sprintf("  %-6s %-10s %-5s %-8s %-9s %-6s %-10s", "NODE", "ID", "HTTP", "STAKING", "STATE", "PEERS", "HEALTH")
+	for _, chain := range d.chains {
+		header += fmt.Sprintf(" %-*s", chainColumnWidth, shorten(chain.Name))
+	}
+	b.WriteString(color.New(color.Bold).Sprint(header) + "\n")
+
+	for i, node := range d.nodes {
+		cursor := "  "
+		if i == d.selected {
+			cursor = color.CyanString("> ")
+		}
+		nodeID := strings.TrimPrefix(d.info.Nodes[i].ID, "NodeID-")
+		if len(nodeID) > 8 {
+			nodeID = nodeID[:8]
+		}
+		httpPort := constants.BaseHTTPPort + 2*i
+		fmt.Fprintf(&b, "%s%-6s %-10s %-5d %-8d ", cursor, fmt.Sprintf("node%d", i+1), nodeID, httpPort, httpPort+1)
+
+		state, stateColor := "down", color.RedString
+		switch {
+		case node.status.Stopped:
+			state, stateColor = "stopped", color.YellowString
+		case node.reachable:
+			state, stateColor = "running", color.GreenString
+		case node.status.Running:
+			state, stateColor = "starting", color.YellowString
+		}
+		b.WriteString(stateColor("%-9s", state) + " ")
+
+		peers, healthStr := "-", color.RedString("%-10s", "-")
+		if node.reachable {
+			peers = fmt.Sprintf("%d/%d", node.peers, len(d.nodes)-1)
+			healthStr = color.RedString("%-10s", "unhealthy")
+			if node.healthy {
+				healthStr = color.GreenString("%-10s", "healthy")
+			}
+		}
+		fmt.Fprintf(&b, "%-6s %s", peers, healthStr)
+
+		// Heights are shown green once the chain is bootstrapped
+		for _, chain := range d.chains {
+			cell := "-"
+			if height, ok := node.heights[chain.Name]; ok {
+				cell = fmt.Sprint(height)
+			}
+			cellColor := color.YellowString
+			if node.bootstrapped[chain.Name] {
+				cellColor = color.GreenString
+			}
+			b.WriteString(" " + cellColor("%-*s", chainColumnWidth, cell))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n" + color.New(color.Faint).Sprint("up/down select  s stop  t start  r restart  l logs  q quit") + "\n")
+	if len(d.message) > 0 {
+		b.WriteString(d.message + "\n")
+	}
+	if d.logView {
+		width, _, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width = 120
+		}
+		fmt.Fprintf(&b, "\n%s\n", color.New(color.Bold).Sprintf("node%d logs", d.selected+1))
+		for _, line := range d.logLines {
+			if len(line) > width {
+				line = line[:width]
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	// Raw mode doesn't move back to the start of the line on line feeds
+	fmt.Print(strings.ReplaceAll(b.String(), "\n", "\r\n"))
+}
+
+// shorten cuts [name] to fit in the column of a chain
+func shorten(name string) string {
+	if len(name) > chainColumnWidth {
+		return name[:chainColumnWidth]
+	}
+	return name
+}
@@ -1,12 +1,16 @@
 package utils
 
 import (
+	"bufio"
 	"crypto/x509"
 	_ "embed"
 	"encoding/pem"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/ava-labs/ava-sim/constants"
 
@@ -39,6 +43,87 @@ func CopyFile(src, dst string) error {
 	return out.Close()
 }
 
+// WriteFileAtomic writes [data] to [path] through a temporary file in the same
+// directory so readers never observe a partially written file
+func WriteFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, constants.FilePerms); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// CheckExecutable returns an error unless [path] is an executable file
+func CheckExecutable(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	if fi.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+	return nil
+}
+
+// ParseNodeAssignments parses a comma separated list of "node=value" entries,
+// such as "3=always,5=on-failure:2", into a map from node index (starting at
+// 0) to value
+func ParseNodeAssignments(s string) (map[int]string, error) {
+	assignments := make(map[int]string)
+	if len(s) == 0 {
+		return assignments, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		nodeStr, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q (expected node=value)", entry)
+		}
+		nodeNum, err := strconv.Atoi(nodeStr)
+		if err != nil || nodeNum < 1 || nodeNum > constants.NumNodes {
+			return nil, fmt.Errorf("invalid node %q", nodeStr)
+		}
+		assignments[nodeNum-1] = value
+	}
+	return assignments, nil
+}
+
+// TailFile returns up to the last [n] lines of the file at [path], or nothing
+// if it cannot be read
+func TailFile(path string, n int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	lines := make([]string, 0, n)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(lines) == n {
+			lines = lines[1:]
+		}
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
 func LoadNodeID(stakeCert []byte) (string, error) {
 	block, _ := pem.Decode(stakeCert)
 	cert, err := x509.ParseCertificate(block.Bytes)
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseNodeAssignments(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    map[int]string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			s:    "",
+			want: map[int]string{},
+		},
+		{
+			name: "several nodes",
+			s:    "3=always,5=on-failure:2",
+			want: map[int]string{2: "always", 4: "on-failure:2"},
+		},
+		{
+			name: "value with equal sign",
+			s:    "1=a=b",
+			want: map[int]string{0: "a=b"},
+		},
+		{
+			name: "empty value",
+			s:    "2=",
+			want: map[int]string{1: ""},
+		},
+		{
+			name:    "missing value",
+			s:       "3",
+			wantErr: true,
+		},
+		{
+			name:    "node 0",
+			s:       "0=always",
+			wantErr: true,
+		},
+		{
+			name:    "node out of range",
+			s:       "6=always",
+			wantErr: true,
+		},
+		{
+			name:    "node not a number",
+			s:       "one=always",
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseNodeAssignments(test.s)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckExecutable(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "avalanchego")
+	if err := os.WriteFile(executable, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	notExecutable := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(notExecutable, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "executable", path: executable},
+		{name: "not executable", path: notExecutable, wantErr: true},
+		{name: "directory", path: dir, wantErr: true},
+		{name: "missing", path: filepath.Join(dir, "missing"), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := CheckExecutable(test.path); (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}